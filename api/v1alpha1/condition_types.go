@@ -84,4 +84,16 @@ const (
 
 	// TransferFailedReason is used when we fail to transfer a component.
 	TransferFailedReason = "TransferFailed"
+
+	// SnapshotRevisionNotFoundReason is used when the requested snapshot revision is not retained.
+	SnapshotRevisionNotFoundReason = "SnapshotRevisionNotFound"
+
+	// SnapshotDataMissingReason is used when the data of a snapshot is no longer present in the cache.
+	SnapshotDataMissingReason = "SnapshotDataMissing"
+
+	// SnapshotDataVerificationFailedReason is used when the cached data of a snapshot doesn't match its digest.
+	SnapshotDataVerificationFailedReason = "SnapshotDataVerificationFailed"
+
+	// ResourceDigestMismatchReason is used when the content of a resource doesn't match the digest in the component descriptor.
+	ResourceDigestMismatchReason = "ResourceDigestMismatch"
 )
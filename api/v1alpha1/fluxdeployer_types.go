@@ -30,6 +30,11 @@ type FluxDeployerSpec struct {
 	// WaitForReady if set will wait for all created resources to be ready before itself becomes Ready.
 	// +optional
 	WaitForReady bool `json:"waitForReady,omitempty"`
+
+	// Revision pins the deployment to a revision retained by the source snapshot, identified by
+	// its digest or tag. If empty, the latest revision of the snapshot is deployed.
+	// +optional
+	Revision string `json:"revision,omitempty"`
 }
 
 // FluxDeployerStatus defines the observed state of FluxDeployer.
@@ -1,8 +1,11 @@
 package v1alpha1
 
 import (
+	"bytes"
+	"encoding/json"
 	"time"
 
+	"github.com/fluxcd/pkg/apis/kustomize"
 	"github.com/fluxcd/pkg/apis/meta"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,6 +20,9 @@ type MutationObject interface {
 }
 
 // MutationSpec defines a common spec for Localization and Configuration of OCM resources.
+// Only one of ConfigRef, PatchStrategicMerge, PatchJSON6902, YQ, Kustomize and HelmTemplate can be set,
+// AutoLocalize is applied on top of any of them.
+// +kubebuilder:validation:XValidation:rule="[has(self.configRef), has(self.patchStrategicMerge), has(self.patchJSON6902), has(self.yq), has(self.kustomize), has(self.helmTemplate)].filter(x, x).size() <= 1",message="only one of configRef, patchStrategicMerge, patchJSON6902, yq, kustomize and helmTemplate can be set"
 type MutationSpec struct {
 	// +required
 	Interval metav1.Duration `json:"interval,omitempty"`
@@ -30,12 +36,70 @@ type MutationSpec struct {
 	// +optional
 	Values *apiextensionsv1.JSON `json:"values,omitempty"`
 
+	// ValuesFrom lists the sources of the values in ascending order of precedence. The values of the
+	// sources are deep merged, the values of a later source override the values of the earlier ones and
+	// the inline Values override the values of all sources. Lists are replaced, not merged.
+	// A single source which isn't wrapped in a list is accepted as well.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:XValidation:rule="type(self) == map || (type(self) == list && self.all(s, type(s) == map))",message="valuesFrom must be a values source or a list of values sources"
 	// +optional
-	ValuesFrom *ValuesSource `json:"valuesFrom,omitempty"`
+	ValuesFrom ValuesSources `json:"valuesFrom,omitempty"`
+
+	// Profile selects a profile of the config data. The defaults of the profile overlay the defaults
+	// of the config data and its rules are applied after the rules of the config data.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// Decryption decrypts the SOPS encrypted files of the values of Flux sources, of the
+	// PatchStrategicMerge source and of the config data. Files which aren't encrypted are used as they are.
+	// +optional
+	Decryption *Decryption `json:"decryption,omitempty"`
 
 	// +optional
 	PatchStrategicMerge *PatchStrategicMerge `json:"patchStrategicMerge,omitempty"`
 
+	// PatchJSON6902 applies RFC 6902 JSON patches to the objects of the source.
+	// +optional
+	PatchJSON6902 []JSON6902Patch `json:"patchJSON6902,omitempty"`
+
+	// YQ applies yq expressions to the files of the source.
+	// +optional
+	YQ []YQExpression `json:"yq,omitempty"`
+
+	// Kustomize renders the source with a kustomize overlay.
+	// +optional
+	Kustomize *KustomizeOverlay `json:"kustomize,omitempty"`
+
+	// HelmTemplate renders a Helm chart source into plain manifests using Values or ValuesFrom
+	// as the chart values.
+	// +optional
+	HelmTemplate *HelmTemplate `json:"helmTemplate,omitempty"`
+
+	// AutoLocalize rewrites the container images found in the source to the references of the matching
+	// ociImage resources of the component graph, without requiring localization rules.
+	// +optional
+	AutoLocalize *AutoLocalization `json:"autoLocalize,omitempty"`
+
+	// Platform is the default platform of the localization rules for ociImage resources which don't
+	// select one. Images pointing to a multi-arch image index are localized to the manifest digest of
+	// the platform.
+	// +optional
+	Platform *Platform `json:"platform,omitempty"`
+
+	// LocalizationOutput selects how the localization rules are applied. Substitute rewrites the
+	// files in place. KustomizeImages leaves the files untouched and writes the localized images to
+	// the images transformer of the kustomization.yaml in the root of the source, creating it if needed.
+	// The container images found by automatic localization are written to the transformer as well.
+	// +kubebuilder:validation:Enum=Substitute;KustomizeImages
+	// +optional
+	LocalizationOutput LocalizationOutput `json:"localizationOutput,omitempty"`
+
+	// DryRun renders the mutation without updating the snapshot. The changes of the rendered files
+	// against the current snapshot are published in the dry run ConfigMap and listed in the status.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
 	// Suspend stops all operations on this object.
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
@@ -43,18 +107,65 @@ type MutationSpec struct {
 
 // ValuesSource provides access to values from an external Source such as a ConfigMap or GitRepository or ObjectReference.
 // An optional subpath defines the path within the source from which the values should be resolved.
+// Exactly one source has to be set.
 type ValuesSource struct {
 	// +optional
 	FluxSource *FluxValuesSource `json:"fluxSource,omitempty"`
 	// +optional
 	ConfigMapSource *ConfigMapSource `json:"configMapSource,omitempty"`
 	// +optional
+	SecretSource *SecretSource `json:"secretSource,omitempty"`
+	// SourceRef reads the values from the output of another object such as a Configuration.
+	// +optional
 	SourceRef *ObjectReference `json:"sourceRef,omitempty"`
+	// Path is the path of the values file within the output of the SourceRef when the output is an
+	// archive of files, as the output of a Configuration or Localization. Defaults to values.yaml.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// SubPath defines the path within the values of the SourceRef from which the values should be resolved.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+	// Optional marks the SourceRef as optional. When set, a not found error
+	// for the referenced object is ignored.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ValuesSources is a list of values sources. It is decoded from a single source as well, the form of
+// ValuesFrom before it became a list, so existing objects keep working.
+type ValuesSources []ValuesSource
+
+// UnmarshalJSON decodes a list of values sources or a single one.
+func (s *ValuesSources) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var source ValuesSource
+		if err := json.Unmarshal(data, &source); err != nil {
+			return err
+		}
+
+		*s = ValuesSources{source}
+
+		return nil
+	}
+
+	var sources []ValuesSource
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return err
+	}
+
+	*s = sources
+
+	return nil
 }
 
 type ConfigMapSource struct {
 	// +required
 	SourceRef meta.LocalObjectReference `json:"sourceRef"`
+	// Namespace of the ConfigMap, defaults to the namespace of the object. A ConfigMap of another
+	// namespace must grant access to the namespace of the object, either with the
+	// delivery.ocm.software/values-source-grant annotation or with a ValuesSourceGrant.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 	// +required
 	Key string `json:"key"`
 	// +optional
@@ -66,6 +177,27 @@ type ConfigMapSource struct {
 	Optional bool `json:"optional,omitempty"`
 }
 
+// SecretSource reads the values from a key of a Secret. The values are redacted from the events,
+// logs, status messages and errors of the object.
+type SecretSource struct {
+	// +required
+	SourceRef meta.LocalObjectReference `json:"sourceRef"`
+	// Namespace of the Secret, defaults to the namespace of the object. A Secret of another
+	// namespace must grant access to the namespace of the object, either with the
+	// delivery.ocm.software/values-source-grant annotation or with a ValuesSourceGrant.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// +required
+	Key string `json:"key"`
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+	// Optional marks this SecretSource as optional. When set, a not found
+	// error for the secret reference is ignored, but any Key, Subpath or
+	// transient error will still result in a reconciliation failure.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
 type FluxValuesSource struct {
 	// +required
 	SourceRef meta.NamespacedObjectKindReference `json:"sourceRef"`
@@ -75,6 +207,12 @@ type FluxValuesSource struct {
 
 	// +optional
 	SubPath string `json:"subPath,omitempty"`
+
+	// Optional marks this FluxValuesSource as optional. When set, a not found
+	// error for the source reference is ignored, but any Path, Subpath or
+	// transient error will still result in a reconciliation failure.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 // PatchStrategicMerge contains the source and target details required to perform a strategic merge.
@@ -100,6 +238,166 @@ type PatchStrategicMergeTarget struct {
 	Path string `json:"path"`
 }
 
+// JSON6902Patch contains RFC 6902 JSON patch operations and selects the files and objects they are applied to.
+type JSON6902Patch struct {
+	// Path is a glob pattern, as supported by filepath.Match, selecting the files within the source
+	// the patch is applied to, e.g. "manifests/*.yaml".
+	// +required
+	Path string `json:"path"`
+
+	kustomize.JSON6902Patch `json:",inline"`
+}
+
+// YQExpression contains a yq expression and selects the files it is applied to.
+type YQExpression struct {
+	// Path is a glob pattern, as supported by filepath.Match, selecting the files within the source
+	// the expression is applied to, e.g. "manifests/*.yaml".
+	// +required
+	Path string `json:"path"`
+
+	// Expression is evaluated against every document of the selected files, e.g.
+	// 'del(.spec.template.spec.containers[0].resources)'.
+	// +required
+	Expression string `json:"expression"`
+}
+
+// KustomizeOverlay contains the details required to render the source with a kustomize overlay.
+type KustomizeOverlay struct {
+	// SourceRef references the GitRepository, Resource, Configuration or Localization holding the overlay.
+	// +required
+	SourceRef meta.NamespacedObjectKindReference `json:"sourceRef"`
+
+	// Path is the path of the directory within the overlay source that contains the kustomization file.
+	// +required
+	Path string `json:"path"`
+
+	// BasePath is the path of the directory within the overlay source the source is extracted to, replacing
+	// any existing content. Overlays reference it like their usual base, e.g. "../../base".
+	// +kubebuilder:default=base
+	// +optional
+	BasePath string `json:"basePath,omitempty"`
+}
+
+// GetBasePath returns the directory within the overlay source the source is extracted to.
+func (in KustomizeOverlay) GetBasePath() string {
+	if in.BasePath == "" {
+		return "base"
+	}
+
+	return in.BasePath
+}
+
+// HelmTemplate contains the options used to render a Helm chart, equivalent to `helm template`.
+// Chart hooks are not part of the rendered manifests.
+type HelmTemplate struct {
+	// ReleaseName is the name of the rendered release. Defaults to the name of the mutation object.
+	// +optional
+	ReleaseName string `json:"releaseName,omitempty"`
+
+	// Namespace is the namespace of the rendered release. Defaults to the namespace of the mutation object.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// KubeVersion is the Kubernetes version used for Capabilities.KubeVersion, e.g. "1.30.0".
+	// +optional
+	KubeVersion string `json:"kubeVersion,omitempty"`
+
+	// APIVersions are added to the API versions used for Capabilities.APIVersions, e.g. "monitoring.coreos.com/v1".
+	// +optional
+	APIVersions []string `json:"apiVersions,omitempty"`
+
+	// IncludeCRDs includes the CRDs of the chart in the rendered manifests.
+	// +optional
+	IncludeCRDs bool `json:"includeCRDs,omitempty"`
+}
+
+// AutoLocalization configures the automatic localization of container images. The images of Pods,
+// Deployments, StatefulSets, DaemonSets, Jobs and CronJobs are matched against the ociImage resources
+// of the ComponentVersion referenced by SourceRef or ConfigRef and its references.
+type AutoLocalization struct {
+	// ExtraPaths are JSONPath expressions selecting additional fields holding full image references
+	// in every document of the source, e.g. "$.spec.values.image".
+	// +optional
+	ExtraPaths []string `json:"extraPaths,omitempty"`
+
+	// MatchLabel is the name of a resource label whose value is the image repository the resource
+	// localizes, e.g. "ghcr.io/stefanprodan/podinfo". Images are matched by this label before they
+	// are matched by repository path regardless of the registry. The label is needed when a transfer
+	// changes the repository path of the resource.
+	// +optional
+	MatchLabel string `json:"matchLabel,omitempty"`
+}
+
+// LocalizationOutput is the way localization rules are applied to the source.
+type LocalizationOutput string
+
+const (
+	// SubstituteOutput substitutes the localized values in the source files.
+	SubstituteOutput LocalizationOutput = "Substitute"
+	// KustomizeImagesOutput writes the localized images to a kustomize images transformer.
+	KustomizeImagesOutput LocalizationOutput = "KustomizeImages"
+)
+
+// SOPSProvider decrypts files encrypted by SOPS.
+const SOPSProvider = "sops"
+
+// Decryption defines how to decrypt encrypted files.
+type Decryption struct {
+	// Provider is the decryption engine.
+	// +kubebuilder:validation:Enum=sops
+	// +required
+	Provider string `json:"provider"`
+
+	// SecretRef references the Secret holding the age identities used to decrypt the files, in
+	// the keys ending with ".agekey". The decrypted content is only held in memory and in the
+	// temporary directories of the mutation.
+	// +required
+	SecretRef meta.LocalObjectReference `json:"secretRef"`
+}
+
+// Platform selects the manifest of a multi-arch image index.
+type Platform struct {
+	// OS is the operating system, e.g. "linux".
+	// +required
+	OS string `json:"os"`
+
+	// Architecture is the CPU architecture, e.g. "arm64".
+	// +required
+	Architecture string `json:"architecture"`
+
+	// Variant is the variant of the CPU architecture, e.g. "v7".
+	// +optional
+	Variant string `json:"variant,omitempty"`
+}
+
+// LocalizedImage describes an image reference found by automatic localization.
+type LocalizedImage struct {
+	// File is the path of the file within the source.
+	File string `json:"file"`
+
+	// Object is the kind and name of the object holding the image.
+	// +optional
+	Object string `json:"object,omitempty"`
+
+	// Path is the path expression that selected the image.
+	Path string `json:"path"`
+
+	// Image is the image reference found in the source.
+	Image string `json:"image"`
+
+	// Localized is the reference the image has been rewritten to.
+	// +optional
+	Localized string `json:"localized,omitempty"`
+
+	// Component is the name of the component holding the matching resource.
+	// +optional
+	Component string `json:"component,omitempty"`
+
+	// Resource is the name of the matching resource.
+	// +optional
+	Resource string `json:"resource,omitempty"`
+}
+
 // GetRequeueAfter returns the duration after which the Localization must be
 // reconciled again.
 func (in MutationSpec) GetRequeueAfter() time.Duration {
@@ -129,4 +427,139 @@ type MutationStatus struct {
 
 	// +optional
 	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// LocalizedImages lists the images rewritten by automatic localization.
+	// +optional
+	LocalizedImages []LocalizedImage `json:"localizedImages,omitempty"`
+
+	// UnmatchedImages lists the images found by automatic localization without a matching resource.
+	// +optional
+	UnmatchedImages []LocalizedImage `json:"unmatchedImages,omitempty"`
+
+	// ResolvedDigests records the digests resolved from the registry for image tags pinned by
+	// localization rules. Recorded digests are used instead of resolving the tags again.
+	// +optional
+	ResolvedDigests []ResolvedDigest `json:"resolvedDigests,omitempty"`
+
+	// RewrittenReferences records the image references rewritten by registry rewrite policies.
+	// +optional
+	RewrittenReferences []RewrittenReference `json:"rewrittenReferences,omitempty"`
+
+	// ValuesSources records the digest and version of the values contributed by each source of
+	// ValuesFrom in ascending order of precedence. Optional sources which weren't found aren't recorded.
+	// +optional
+	ValuesSources []ValuesSourceDigest `json:"valuesSources,omitempty"`
+
+	// ValuesSchema references the ConfigMap publishing the JSON schema of the values, taken from the
+	// config data of the latest config version.
+	// +optional
+	ValuesSchema *ValuesSchema `json:"valuesSchema,omitempty"`
+
+	// ValuesValidationErrors lists the values violating the schema of the config data by field path.
+	// +optional
+	ValuesValidationErrors []ValuesValidationError `json:"valuesValidationErrors,omitempty"`
+
+	// DryRun describes the changes of the last dry run against the snapshot.
+	// +optional
+	DryRun *DryRunResult `json:"dryRun,omitempty"`
+}
+
+// DryRunResult describes the changes a rendering of the mutation would make to the snapshot.
+type DryRunResult struct {
+	// ConfigMapRef references the ConfigMap holding the unified diffs of the changes in its "diff.patch" key.
+	ConfigMapRef meta.LocalObjectReference `json:"configMapRef"`
+
+	// SnapshotDigest is the digest of the snapshot the rendering has been compared with. It's empty if
+	// the snapshot doesn't exist yet.
+	// +optional
+	SnapshotDigest string `json:"snapshotDigest,omitempty"`
+
+	// Files lists the files added, removed or changed by the rendering sorted by path.
+	// +optional
+	Files []FileChange `json:"files,omitempty"`
+}
+
+// FileChangeType is the way a file is changed by a rendering.
+type FileChangeType string
+
+const (
+	// FileAdded is a file which doesn't exist in the snapshot.
+	FileAdded FileChangeType = "Added"
+	// FileRemoved is a file of the snapshot which isn't rendered anymore.
+	FileRemoved FileChangeType = "Removed"
+	// FileChanged is a file of the snapshot whose content changes.
+	FileChanged FileChangeType = "Changed"
+)
+
+// FileChange describes the change of a file by a rendering.
+type FileChange struct {
+	// Path is the path of the file relative to the root of the snapshot.
+	Path string `json:"path"`
+
+	// Change is the way the file is changed.
+	// +kubebuilder:validation:Enum=Added;Removed;Changed
+	Change FileChangeType `json:"change"`
+
+	// Truncated reports that the diff of the file has been truncated or omitted to keep the
+	// ConfigMap below its size limit.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ValuesSchema references the published JSON schema of the values.
+type ValuesSchema struct {
+	// ConfigMapRef references the ConfigMap holding the schema in its "schema.json" key.
+	ConfigMapRef meta.LocalObjectReference `json:"configMapRef"`
+
+	// Digest is the digest of the schema.
+	Digest string `json:"digest"`
+}
+
+// ValuesValidationError describes a value violating the schema of the config data.
+type ValuesValidationError struct {
+	// Field is the path of the value, e.g. "database.port", or "(root)" for the values as a whole.
+	Field string `json:"field"`
+
+	// Type is the kind of the violation, e.g. "required" or "invalid_type".
+	Type string `json:"type"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+// ValuesSourceDigest records the digest and version of the values contributed by a values source.
+type ValuesSourceDigest struct {
+	// Source is the kind, namespace and name of the source, e.g. "ConfigMap/default/values".
+	Source string `json:"source"`
+
+	// Digest is the digest of the values contributed by the source after resolving the subpath.
+	Digest string `json:"digest"`
+
+	// Version is the version of the source the values were read from: the resource version of
+	// ConfigMaps and Secrets, the artifact revision of Flux sources and the snapshot digest of
+	// other objects.
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// RewrittenReference records an image reference rewritten by a registry rewrite policy.
+type RewrittenReference struct {
+	// Reference is the image reference before it was rewritten.
+	Reference string `json:"reference"`
+
+	// Rewritten is the image reference after it was rewritten.
+	Rewritten string `json:"rewritten"`
+
+	// Policy is the kind and name of the policy which rewrote the reference, e.g.
+	// "ClusterRegistryRewritePolicy/harbor" or "RegistryRewritePolicy/default/harbor".
+	Policy string `json:"policy"`
+}
+
+// ResolvedDigest records the digest an image tag has been resolved to.
+type ResolvedDigest struct {
+	// Reference is the image reference holding the tag.
+	Reference string `json:"reference"`
+
+	// Digest is the digest of the manifest the tag pointed to when it was resolved.
+	Digest string `json:"digest"`
 }
@@ -0,0 +1,87 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RegistryRewritePolicyKind        = "RegistryRewritePolicy"
+	ClusterRegistryRewritePolicyKind = "ClusterRegistryRewritePolicy"
+)
+
+// RegistryRewritePolicySpec defines the rewrite rules applied to the image references resolved
+// during localization.
+type RegistryRewritePolicySpec struct {
+	// Rules are evaluated in order, the first matching rule rewrites the reference.
+	// +required
+	Rules []RegistryRewriteRule `json:"rules"`
+}
+
+// RegistryRewriteRule rewrites image references matching either a prefix or a regular expression.
+// References are matched in their fully qualified form including the tag or digest, e.g.
+// "ghcr.io/org/x:v1.0.0" or "docker.io/library/nginx:1.25".
+type RegistryRewriteRule struct {
+	// Prefix matches references starting with the prefix. The prefix is replaced by Replace.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Regex matches references with a regular expression. The matching part of the reference is
+	// replaced by Replace, which may refer to capture groups, e.g. "harbor.internal/$1".
+	// +optional
+	Regex string `json:"regex,omitempty"`
+
+	// Replace is the replacement of the matched part of the reference.
+	// +required
+	Replace string `json:"replace"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName=rrp
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// RegistryRewritePolicy is the Schema for the registryrewritepolicies API. The policies of a namespace
+// are applied to the Localizations and Configurations of the namespace.
+type RegistryRewritePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RegistryRewritePolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RegistryRewritePolicyList contains a list of RegistryRewritePolicy.
+type RegistryRewritePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RegistryRewritePolicy `json:"items"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster,shortName=crrp
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// ClusterRegistryRewritePolicy is the Schema for the clusterregistryrewritepolicies API. The policies
+// are applied to the Localizations and Configurations of all namespaces before the namespaced policies.
+type ClusterRegistryRewritePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RegistryRewritePolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterRegistryRewritePolicyList contains a list of ClusterRegistryRewritePolicy.
+type ClusterRegistryRewritePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterRegistryRewritePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&RegistryRewritePolicy{}, &RegistryRewritePolicyList{},
+		&ClusterRegistryRewritePolicy{}, &ClusterRegistryRewritePolicyList{},
+	)
+}
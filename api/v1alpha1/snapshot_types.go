@@ -47,6 +47,44 @@ type SnapshotStatus struct {
 	// ObservedGeneration is the last reconciled generation.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Revisions lists the snapshot revisions retained in the cache, newest first.
+	// +optional
+	Revisions []SnapshotRevision `json:"revisions,omitempty"`
+}
+
+// SnapshotRevision describes a previous rendering of a snapshot that is kept in the cache.
+type SnapshotRevision struct {
+	// Digest of the snapshot data.
+	Digest string `json:"digest"`
+
+	// Tag under which the revision is retained in the cache.
+	Tag string `json:"tag"`
+
+	// Repository in the cache the revision is retained in. The repository name is derived from the
+	// identity of the snapshot, so revisions rendered from other inputs, e.g. a previous component
+	// version, live in other repositories than the current snapshot.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// Created is the time the revision was pushed.
+	Created metav1.Time `json:"created"`
+
+	// ComponentVersion is the version of the component the revision was produced from.
+	// +optional
+	ComponentVersion string `json:"componentVersion,omitempty"`
+
+	// ResourceVersion is the version of the resource the revision was produced from.
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// SourceDigest is the digest of the data that has been mutated.
+	// +optional
+	SourceDigest string `json:"sourceDigest,omitempty"`
+
+	// ConfigDigest is the digest of the configuration applied to the source.
+	// +optional
+	ConfigDigest string `json:"configDigest,omitempty"`
 }
 
 func (in *Snapshot) GetVID() map[string]string {
@@ -60,6 +98,27 @@ func (in *Snapshot) SetObservedGeneration(v int64) {
 	in.Status.ObservedGeneration = v
 }
 
+// GetRevision returns the retained revision matching the given digest or tag.
+func (in Snapshot) GetRevision(revision string) (SnapshotRevision, bool) {
+	for _, r := range in.Status.Revisions {
+		if r.Digest == revision || r.Tag == revision {
+			return r, true
+		}
+	}
+
+	return SnapshotRevision{}, false
+}
+
+// GetRepository returns the repository the revision is retained in. Revisions recorded without a
+// repository have been retained in the repository of the snapshot at the time, current is the best guess.
+func (in SnapshotRevision) GetRepository(current string) string {
+	if in.Repository != "" {
+		return in.Repository
+	}
+
+	return current
+}
+
 // GetComponentVersion returns the component version for the snapshot.
 func (in Snapshot) GetComponentVersion() string {
 	return in.Spec.Identity[ComponentVersionKey]
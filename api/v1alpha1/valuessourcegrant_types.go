@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ValuesSourceGrantKind = "ValuesSourceGrant"
+
+	// ValuesSourceGrantAnnotation grants the namespaces listed in its comma separated value access to
+	// the ConfigMap or Secret it is set on as values source. The value "*" grants access to all namespaces.
+	ValuesSourceGrantAnnotation = "delivery.ocm.software/values-source-grant"
+)
+
+// ValuesSourceGrantSpec defines the namespaces allowed to read ConfigMaps and Secrets of the namespace
+// of the grant as values sources.
+type ValuesSourceGrantSpec struct {
+	// From lists the namespaces which are granted access. "*" grants access to all namespaces.
+	// +required
+	From []string `json:"from"`
+
+	// To lists the objects which may be read.
+	// +required
+	To []ValuesSourceGrantTarget `json:"to"`
+}
+
+// ValuesSourceGrantTarget selects the objects which may be read through a grant.
+type ValuesSourceGrantTarget struct {
+	// Kind is the kind of the objects.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	// +required
+	Kind string `json:"kind"`
+
+	// Name is the name of the object. All objects of the kind are selected if it is empty.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName=vsg
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// ValuesSourceGrant is the Schema for the valuessourcegrants API. A grant allows Configurations and
+// Localizations of other namespaces to read ConfigMaps and Secrets of its namespace as values sources.
+type ValuesSourceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ValuesSourceGrantSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ValuesSourceGrantList contains a list of ValuesSourceGrant.
+type ValuesSourceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ValuesSourceGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ValuesSourceGrant{}, &ValuesSourceGrantList{})
+}
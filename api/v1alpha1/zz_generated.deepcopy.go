@@ -14,6 +14,84 @@ import (
 	compdescmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoLocalization) DeepCopyInto(out *AutoLocalization) {
+	*out = *in
+	if in.ExtraPaths != nil {
+		in, out := &in.ExtraPaths, &out.ExtraPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoLocalization.
+func (in *AutoLocalization) DeepCopy() *AutoLocalization {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoLocalization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRegistryRewritePolicy) DeepCopyInto(out *ClusterRegistryRewritePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRegistryRewritePolicy.
+func (in *ClusterRegistryRewritePolicy) DeepCopy() *ClusterRegistryRewritePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRegistryRewritePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterRegistryRewritePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRegistryRewritePolicyList) DeepCopyInto(out *ClusterRegistryRewritePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterRegistryRewritePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRegistryRewritePolicyList.
+func (in *ClusterRegistryRewritePolicyList) DeepCopy() *ClusterRegistryRewritePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRegistryRewritePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterRegistryRewritePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentDescriptor) DeepCopyInto(out *ComponentDescriptor) {
 	*out = *in
@@ -291,6 +369,43 @@ func (in *ConfigurationList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Decryption) DeepCopyInto(out *Decryption) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Decryption.
+func (in *Decryption) DeepCopy() *Decryption {
+	if in == nil {
+		return nil
+	}
+	out := new(Decryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunResult) DeepCopyInto(out *DryRunResult) {
+	*out = *in
+	out.ConfigMapRef = in.ConfigMapRef
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]FileChange, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunResult.
+func (in *DryRunResult) DeepCopy() *DryRunResult {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ElementMeta) DeepCopyInto(out *ElementMeta) {
 	*out = *in
@@ -320,6 +435,21 @@ func (in *ElementMeta) DeepCopy() *ElementMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileChange) DeepCopyInto(out *FileChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileChange.
+func (in *FileChange) DeepCopy() *FileChange {
+	if in == nil {
+		return nil
+	}
+	out := new(FileChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FluxDeployer) DeepCopyInto(out *FluxDeployer) {
 	*out = *in
@@ -444,6 +574,58 @@ func (in *FluxValuesSource) DeepCopy() *FluxValuesSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmTemplate) DeepCopyInto(out *HelmTemplate) {
+	*out = *in
+	if in.APIVersions != nil {
+		in, out := &in.APIVersions, &out.APIVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmTemplate.
+func (in *HelmTemplate) DeepCopy() *HelmTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JSON6902Patch) DeepCopyInto(out *JSON6902Patch) {
+	*out = *in
+	in.JSON6902Patch.DeepCopyInto(&out.JSON6902Patch)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JSON6902Patch.
+func (in *JSON6902Patch) DeepCopy() *JSON6902Patch {
+	if in == nil {
+		return nil
+	}
+	out := new(JSON6902Patch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KustomizeOverlay) DeepCopyInto(out *KustomizeOverlay) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizeOverlay.
+func (in *KustomizeOverlay) DeepCopy() *KustomizeOverlay {
+	if in == nil {
+		return nil
+	}
+	out := new(KustomizeOverlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Localization) DeepCopyInto(out *Localization) {
 	*out = *in
@@ -503,6 +685,21 @@ func (in *LocalizationList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalizedImage) DeepCopyInto(out *LocalizedImage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalizedImage.
+func (in *LocalizedImage) DeepCopy() *LocalizedImage {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalizedImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MutationSpec) DeepCopyInto(out *MutationSpec) {
 	*out = *in
@@ -520,14 +717,53 @@ func (in *MutationSpec) DeepCopyInto(out *MutationSpec) {
 	}
 	if in.ValuesFrom != nil {
 		in, out := &in.ValuesFrom, &out.ValuesFrom
-		*out = new(ValuesSource)
-		(*in).DeepCopyInto(*out)
+		*out = make(ValuesSources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Decryption != nil {
+		in, out := &in.Decryption, &out.Decryption
+		*out = new(Decryption)
+		**out = **in
 	}
 	if in.PatchStrategicMerge != nil {
 		in, out := &in.PatchStrategicMerge, &out.PatchStrategicMerge
 		*out = new(PatchStrategicMerge)
 		**out = **in
 	}
+	if in.PatchJSON6902 != nil {
+		in, out := &in.PatchJSON6902, &out.PatchJSON6902
+		*out = make([]JSON6902Patch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.YQ != nil {
+		in, out := &in.YQ, &out.YQ
+		*out = make([]YQExpression, len(*in))
+		copy(*out, *in)
+	}
+	if in.Kustomize != nil {
+		in, out := &in.Kustomize, &out.Kustomize
+		*out = new(KustomizeOverlay)
+		**out = **in
+	}
+	if in.HelmTemplate != nil {
+		in, out := &in.HelmTemplate, &out.HelmTemplate
+		*out = new(HelmTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoLocalize != nil {
+		in, out := &in.AutoLocalize, &out.AutoLocalize
+		*out = new(AutoLocalization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Platform != nil {
+		in, out := &in.Platform, &out.Platform
+		*out = new(Platform)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MutationSpec.
@@ -550,6 +786,46 @@ func (in *MutationStatus) DeepCopyInto(out *MutationStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LocalizedImages != nil {
+		in, out := &in.LocalizedImages, &out.LocalizedImages
+		*out = make([]LocalizedImage, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnmatchedImages != nil {
+		in, out := &in.UnmatchedImages, &out.UnmatchedImages
+		*out = make([]LocalizedImage, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResolvedDigests != nil {
+		in, out := &in.ResolvedDigests, &out.ResolvedDigests
+		*out = make([]ResolvedDigest, len(*in))
+		copy(*out, *in)
+	}
+	if in.RewrittenReferences != nil {
+		in, out := &in.RewrittenReferences, &out.RewrittenReferences
+		*out = make([]RewrittenReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValuesSources != nil {
+		in, out := &in.ValuesSources, &out.ValuesSources
+		*out = make([]ValuesSourceDigest, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValuesSchema != nil {
+		in, out := &in.ValuesSchema, &out.ValuesSchema
+		*out = new(ValuesSchema)
+		**out = **in
+	}
+	if in.ValuesValidationErrors != nil {
+		in, out := &in.ValuesValidationErrors, &out.ValuesValidationErrors
+		*out = make([]ValuesValidationError, len(*in))
+		copy(*out, *in)
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(DryRunResult)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MutationStatus.
@@ -631,6 +907,21 @@ func (in *PatchStrategicMergeTarget) DeepCopy() *PatchStrategicMergeTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Platform) DeepCopyInto(out *Platform) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Platform.
+func (in *Platform) DeepCopy() *Platform {
+	if in == nil {
+		return nil
+	}
+	out := new(Platform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PublicKey) DeepCopyInto(out *PublicKey) {
 	*out = *in
@@ -681,6 +972,99 @@ func (in *Reference) DeepCopy() *Reference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryRewritePolicy) DeepCopyInto(out *RegistryRewritePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryRewritePolicy.
+func (in *RegistryRewritePolicy) DeepCopy() *RegistryRewritePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryRewritePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegistryRewritePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryRewritePolicyList) DeepCopyInto(out *RegistryRewritePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RegistryRewritePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryRewritePolicyList.
+func (in *RegistryRewritePolicyList) DeepCopy() *RegistryRewritePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryRewritePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegistryRewritePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryRewritePolicySpec) DeepCopyInto(out *RegistryRewritePolicySpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RegistryRewriteRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryRewritePolicySpec.
+func (in *RegistryRewritePolicySpec) DeepCopy() *RegistryRewritePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryRewritePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryRewriteRule) DeepCopyInto(out *RegistryRewriteRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryRewriteRule.
+func (in *RegistryRewriteRule) DeepCopy() *RegistryRewriteRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryRewriteRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Repository) DeepCopyInto(out *Repository) {
 	*out = *in
@@ -701,6 +1085,21 @@ func (in *Repository) DeepCopy() *Repository {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedDigest) DeepCopyInto(out *ResolvedDigest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedDigest.
+func (in *ResolvedDigest) DeepCopy() *ResolvedDigest {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedDigest)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resource) DeepCopyInto(out *Resource) {
 	*out = *in
@@ -828,6 +1227,37 @@ func (in *ResourceStatus) DeepCopy() *ResourceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RewrittenReference) DeepCopyInto(out *RewrittenReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RewrittenReference.
+func (in *RewrittenReference) DeepCopy() *RewrittenReference {
+	if in == nil {
+		return nil
+	}
+	out := new(RewrittenReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretSource) DeepCopyInto(out *SecretSource) {
+	*out = *in
+	out.SourceRef = in.SourceRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretSource.
+func (in *SecretSource) DeepCopy() *SecretSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Signature) DeepCopyInto(out *Signature) {
 	*out = *in
@@ -903,6 +1333,22 @@ func (in *SnapshotList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotRevision) DeepCopyInto(out *SnapshotRevision) {
+	*out = *in
+	in.Created.DeepCopyInto(&out.Created)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotRevision.
+func (in *SnapshotRevision) DeepCopy() *SnapshotRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotSpec) DeepCopyInto(out *SnapshotSpec) {
 	*out = *in
@@ -935,6 +1381,13 @@ func (in *SnapshotStatus) DeepCopyInto(out *SnapshotStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]SnapshotRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotStatus.
@@ -976,6 +1429,22 @@ func (in *SnapshotTemplateSpec) DeepCopy() *SnapshotTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesSchema) DeepCopyInto(out *ValuesSchema) {
+	*out = *in
+	out.ConfigMapRef = in.ConfigMapRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSchema.
+func (in *ValuesSchema) DeepCopy() *ValuesSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSchema)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValuesSource) DeepCopyInto(out *ValuesSource) {
 	*out = *in
@@ -989,6 +1458,11 @@ func (in *ValuesSource) DeepCopyInto(out *ValuesSource) {
 		*out = new(ConfigMapSource)
 		**out = **in
 	}
+	if in.SecretSource != nil {
+		in, out := &in.SecretSource, &out.SecretSource
+		*out = new(SecretSource)
+		**out = **in
+	}
 	if in.SourceRef != nil {
 		in, out := &in.SourceRef, &out.SourceRef
 		*out = new(ObjectReference)
@@ -1006,6 +1480,155 @@ func (in *ValuesSource) DeepCopy() *ValuesSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesSourceDigest) DeepCopyInto(out *ValuesSourceDigest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSourceDigest.
+func (in *ValuesSourceDigest) DeepCopy() *ValuesSourceDigest {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSourceDigest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesSourceGrant) DeepCopyInto(out *ValuesSourceGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSourceGrant.
+func (in *ValuesSourceGrant) DeepCopy() *ValuesSourceGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSourceGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ValuesSourceGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesSourceGrantList) DeepCopyInto(out *ValuesSourceGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ValuesSourceGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSourceGrantList.
+func (in *ValuesSourceGrantList) DeepCopy() *ValuesSourceGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSourceGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ValuesSourceGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesSourceGrantSpec) DeepCopyInto(out *ValuesSourceGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]ValuesSourceGrantTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSourceGrantSpec.
+func (in *ValuesSourceGrantSpec) DeepCopy() *ValuesSourceGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSourceGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesSourceGrantTarget) DeepCopyInto(out *ValuesSourceGrantTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSourceGrantTarget.
+func (in *ValuesSourceGrantTarget) DeepCopy() *ValuesSourceGrantTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSourceGrantTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ValuesSources) DeepCopyInto(out *ValuesSources) {
+	{
+		in := &in
+		*out = make(ValuesSources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesSources.
+func (in ValuesSources) DeepCopy() ValuesSources {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesSources)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesValidationError) DeepCopyInto(out *ValuesValidationError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesValidationError.
+func (in *ValuesValidationError) DeepCopy() *ValuesValidationError {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesValidationError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Version) DeepCopyInto(out *Version) {
 	*out = *in
@@ -1020,3 +1643,18 @@ func (in *Version) DeepCopy() *Version {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *YQExpression) DeepCopyInto(out *YQExpression) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new YQExpression.
+func (in *YQExpression) DeepCopy() *YQExpression {
+	if in == nil {
+		return nil
+	}
+	out := new(YQExpression)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -11,9 +11,11 @@ import (
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/fluxcd/pkg/runtime/patch"
+	"github.com/fluxcd/pkg/runtime/predicates"
 	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	mh "github.com/open-component-model/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -56,6 +58,9 @@ type ConfigurationReconciler struct {
 //+kubebuilder:rbac:groups=delivery.ocm.software,resources=configurations/finalizers,verbs=update
 
 //+kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories;buckets;ocirepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=delivery.ocm.software,resources=valuessourcegrants,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // SetupWithManager sets up the controller with the Manager.
@@ -65,6 +70,9 @@ func (r *ConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		configKey       = ".metadata.config"
 		patchSourceKey  = ".metadata.patchSource"
 		valuesSourceKey = ".metadata.fluxValuesSource"
+		configMapKey    = ".metadata.valuesConfigMap"
+		secretKey       = ".metadata.valuesSecret"
+		grantKey        = ".metadata.valuesSourceNamespace"
 	)
 
 	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.Configuration{}, sourceKey, func(rawObj client.Object) []string {
@@ -127,24 +135,40 @@ func (r *ConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return nil
 		}
 
-		if cfg.Spec.ValuesFrom == nil {
-			return nil
-		}
-		if cfg.Spec.ValuesFrom.FluxSource == nil {
-			return nil
-		}
-		ns := cfg.Spec.ValuesFrom.FluxSource.SourceRef.Namespace
-		if ns == "" {
-			ns = cfg.GetNamespace()
+		var keys []string
+		for _, source := range cfg.Spec.ValuesFrom {
+			if source.FluxSource == nil {
+				continue
+			}
+			ns := source.FluxSource.SourceRef.Namespace
+			if ns == "" {
+				ns = cfg.GetNamespace()
+			}
+
+			keys = append(keys, fmt.Sprintf("%s/%s", ns, source.FluxSource.SourceRef.Name))
 		}
 
-		return []string{fmt.Sprintf("%s/%s", ns, cfg.Spec.ValuesFrom.FluxSource.SourceRef.Name)}
+		return keys
 	}); err != nil {
 		return fmt.Errorf("failed setting index fields: %w", err)
 	}
 
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.Configuration{}, configMapKey, indexValuesSources(valuesConfigMapRef)); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.Configuration{}, secretKey, indexValuesSources(valuesSecretRef)); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.Configuration{}, grantKey, indexValuesSourceNamespaces); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.Configuration{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&v1alpha1.Configuration{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+		)).
 		Watches(
 			&v1alpha1.ComponentVersion{},
 			handler.WithLowPriorityWhenUnchanged(handler.EnqueueRequestsFromMapFunc(r.findObjects(sourceKey, configKey))),
@@ -157,9 +181,26 @@ func (r *ConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		).
 		Watches(
 			&sourcev1.GitRepository{},
-			handler.WithLowPriorityWhenUnchanged(handler.EnqueueRequestsFromMapFunc(r.findObjectsForGitRepository(patchSourceKey, valuesSourceKey))),
+			handler.WithLowPriorityWhenUnchanged(handler.EnqueueRequestsFromMapFunc(r.findObjectsForSource(patchSourceKey, valuesSourceKey))),
 			builder.WithPredicates(SourceRevisionChangePredicate{}),
 		).
+		Watches(
+			valuesSourceMetadata("ConfigMap"),
+			handler.WithLowPriorityWhenUnchanged(handler.EnqueueRequestsFromMapFunc(r.findObjectsForSource(configMapKey))),
+			builder.OnlyMetadata,
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			valuesSourceMetadata("Secret"),
+			handler.WithLowPriorityWhenUnchanged(handler.EnqueueRequestsFromMapFunc(r.findObjectsForSource(secretKey))),
+			builder.OnlyMetadata,
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&v1alpha1.ValuesSourceGrant{},
+			handler.WithLowPriorityWhenUnchanged(handler.EnqueueRequestsFromMapFunc(r.findObjectsForGrant(grantKey))),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
 
@@ -325,6 +366,12 @@ func (r *ConfigurationReconciler) reconcile(
 		return ctrl.Result{}, err
 	}
 
+	if obj.Spec.DryRun {
+		status.MarkReady(r.EventRecorder, obj, "Dry run success: %d files changed", len(obj.Status.DryRun.Files))
+
+		return ctrl.Result{RequeueAfter: obj.GetRequeueAfter()}, nil
+	}
+
 	status.MarkReady(r.EventRecorder, obj, "Reconciliation success")
 
 	metrics.SnapshotNumberOfBytesReconciled.WithLabelValues(obj.GetSnapshotName(), obj.GetSnapshotDigest(), obj.Spec.SourceRef.Name).Set(float64(size))
@@ -376,8 +423,9 @@ func (r *ConfigurationReconciler) findObjects(sourceKey, configKey string) handl
 	}
 }
 
-// this function will enqueue a reconciliation.
-func (r *ConfigurationReconciler) findObjectsForGitRepository(keys ...string) handler.MapFunc {
+// findObjectsForSource enqueues a reconciliation of the configurations referencing the source
+// through any of the index keys.
+func (r *ConfigurationReconciler) findObjectsForSource(keys ...string) handler.MapFunc {
 	return func(ctx context.Context, obj client.Object) []reconcile.Request {
 		cfgs := &v1alpha1.ConfigurationList{}
 		for _, key := range keys {
@@ -394,6 +442,99 @@ func (r *ConfigurationReconciler) findObjectsForGitRepository(keys ...string) ha
 	}
 }
 
+// findObjectsForGrant enqueues a reconciliation of the configurations reading ConfigMaps or Secrets of
+// the namespace of the grant, as the grant may give or revoke their access.
+func (r *ConfigurationReconciler) findObjectsForGrant(key string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		cfgs := &v1alpha1.ConfigurationList{}
+		if err := r.List(ctx, cfgs, &client.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector(key, obj.GetNamespace()),
+		}); err != nil {
+			return []reconcile.Request{}
+		}
+
+		return makeRequestsForConfigurations(cfgs.Items...)
+	}
+}
+
+// indexValuesSourceNamespaces indexes the configuration by the other namespaces it reads ConfigMaps or
+// Secrets of, as its access to them depends on the ValuesSourceGrants of these namespaces.
+func indexValuesSourceNamespaces(rawObj client.Object) []string {
+	cfg, ok := rawObj.(*v1alpha1.Configuration)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, source := range cfg.Spec.ValuesFrom {
+		for _, ref := range []func(v1alpha1.ValuesSource) (string, string){valuesConfigMapRef, valuesSecretRef} {
+			ns, name := ref(source)
+			if name == "" || ns == "" || ns == cfg.GetNamespace() || slices.Contains(keys, ns) {
+				continue
+			}
+
+			keys = append(keys, ns)
+		}
+	}
+
+	return keys
+}
+
+// indexValuesSources returns an index function for the values sources of the configuration. The ref
+// function returns the namespace and name of the indexed object referenced by a source, the name is
+// empty if the source doesn't reference one. The namespace defaults to the one of the configuration.
+func indexValuesSources(ref func(v1alpha1.ValuesSource) (string, string)) client.IndexerFunc {
+	return func(rawObj client.Object) []string {
+		cfg, ok := rawObj.(*v1alpha1.Configuration)
+		if !ok {
+			return nil
+		}
+
+		var keys []string
+		for _, source := range cfg.Spec.ValuesFrom {
+			ns, name := ref(source)
+			if name == "" {
+				continue
+			}
+			if ns == "" {
+				ns = cfg.GetNamespace()
+			}
+
+			keys = append(keys, fmt.Sprintf("%s/%s", ns, name))
+		}
+
+		return keys
+	}
+}
+
+// valuesSourceMetadata returns the object to watch the metadata of the core kind used as values
+// source. Only the metadata of ConfigMaps and Secrets is cached, their data is read from the API
+// server when the configuration is reconciled.
+func valuesSourceMetadata(kind string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       kind,
+		},
+	}
+}
+
+func valuesConfigMapRef(source v1alpha1.ValuesSource) (string, string) {
+	if source.ConfigMapSource == nil {
+		return "", ""
+	}
+
+	return source.ConfigMapSource.Namespace, source.ConfigMapSource.SourceRef.Name
+}
+
+func valuesSecretRef(source v1alpha1.ValuesSource) (string, string) {
+	if source.SecretSource == nil {
+		return "", ""
+	}
+
+	return source.SecretSource.Namespace, source.SecretSource.SourceRef.Name
+}
+
 func (r *ConfigurationReconciler) checkReadiness(
 	ctx context.Context,
 	ns string,
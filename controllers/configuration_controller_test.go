@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fluxcd/pkg/apis/kustomize"
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/conditions"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
@@ -22,18 +23,24 @@ import (
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	k8sapierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/yaml"
 
 	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	ocmcache "github.com/open-component-model/ocm-controller/pkg/cache"
 	cachefakes "github.com/open-component-model/ocm-controller/pkg/cache/fakes"
+	"github.com/open-component-model/ocm-controller/pkg/helm"
+	helmfakes "github.com/open-component-model/ocm-controller/pkg/helm/fakes"
 	"github.com/open-component-model/ocm-controller/pkg/ocm/fakes"
 	ocmsnapshot "github.com/open-component-model/ocm-controller/pkg/snapshot"
 )
@@ -781,6 +788,7 @@ configuration:
 				EventRecorder: recorder,
 				MutationReconciler: MutationReconcileLooper{
 					Client:         client,
+					APIReader:      client,
 					DynamicClient:  dynClient,
 					Scheme:         env.scheme,
 					OCMClient:      fakeOcm,
@@ -868,15 +876,17 @@ func TestConfigurationValuesFrom(t *testing.T) {
 			configuration: func(source client.Object) *v1alpha1.Configuration {
 				configuration := DefaultConfiguration.DeepCopy()
 				configuration.Status.SnapshotName = "configuration-snapshot"
-				configuration.Spec.ValuesFrom = &v1alpha1.ValuesSource{
-					FluxSource: &v1alpha1.FluxValuesSource{
-						SourceRef: meta.NamespacedObjectKindReference{
-							Kind:      "GitRepository",
-							Name:      source.GetName(),
-							Namespace: source.GetNamespace(),
+				configuration.Spec.ValuesFrom = []v1alpha1.ValuesSource{
+					{
+						FluxSource: &v1alpha1.FluxValuesSource{
+							SourceRef: meta.NamespacedObjectKindReference{
+								Kind:      "GitRepository",
+								Name:      source.GetName(),
+								Namespace: source.GetNamespace(),
+							},
+							Path:    "config/values.yaml",
+							SubPath: "test.backend",
 						},
-						Path:    "config/values.yaml",
-						SubPath: "test.backend",
 					},
 				}
 				configuration.Spec.Values = nil
@@ -900,13 +910,15 @@ func TestConfigurationValuesFrom(t *testing.T) {
 			configuration: func(source client.Object) *v1alpha1.Configuration {
 				configuration := DefaultConfiguration.DeepCopy()
 				configuration.Status.SnapshotName = "configuration-snapshot"
-				configuration.Spec.ValuesFrom = &v1alpha1.ValuesSource{
-					ConfigMapSource: &v1alpha1.ConfigMapSource{
-						SourceRef: meta.LocalObjectReference{
-							Name: "test-config-data",
+				configuration.Spec.ValuesFrom = []v1alpha1.ValuesSource{
+					{
+						ConfigMapSource: &v1alpha1.ConfigMapSource{
+							SourceRef: meta.LocalObjectReference{
+								Name: "test-config-data",
+							},
+							Key:     "values.yaml",
+							SubPath: "test.backend",
 						},
-						Key:     "values.yaml",
-						SubPath: "test.backend",
 					},
 				}
 				configuration.Spec.Values = nil
@@ -935,14 +947,16 @@ func TestConfigurationValuesFrom(t *testing.T) {
 			configuration: func(client.Object) *v1alpha1.Configuration {
 				configuration := DefaultConfiguration.DeepCopy()
 				configuration.Status.SnapshotName = "configuration-snapshot"
-				configuration.Spec.ValuesFrom = &v1alpha1.ValuesSource{
-					ConfigMapSource: &v1alpha1.ConfigMapSource{
-						SourceRef: meta.LocalObjectReference{
-							Name: "test-config-data-does-not-exist",
+				configuration.Spec.ValuesFrom = []v1alpha1.ValuesSource{
+					{
+						ConfigMapSource: &v1alpha1.ConfigMapSource{
+							SourceRef: meta.LocalObjectReference{
+								Name: "test-config-data-does-not-exist",
+							},
+							Key:      "values.yaml",
+							SubPath:  "test.backend",
+							Optional: true,
 						},
-						Key:      "values.yaml",
-						SubPath:  "test.backend",
-						Optional: true,
 					},
 				}
 				configuration.Spec.Values = nil
@@ -963,13 +977,15 @@ func TestConfigurationValuesFrom(t *testing.T) {
 			configuration: func(client.Object) *v1alpha1.Configuration {
 				configuration := DefaultConfiguration.DeepCopy()
 				configuration.Status.SnapshotName = "configuration-snapshot"
-				configuration.Spec.ValuesFrom = &v1alpha1.ValuesSource{
-					ConfigMapSource: &v1alpha1.ConfigMapSource{
-						SourceRef: meta.LocalObjectReference{
-							Name: "test-config-data-does-not-exist",
+				configuration.Spec.ValuesFrom = []v1alpha1.ValuesSource{
+					{
+						ConfigMapSource: &v1alpha1.ConfigMapSource{
+							SourceRef: meta.LocalObjectReference{
+								Name: "test-config-data-does-not-exist",
+							},
+							Key:     "values.yaml",
+							SubPath: "test.backend",
 						},
-						Key:     "values.yaml",
-						SubPath: "test.backend",
 					},
 				}
 				configuration.Spec.Values = nil
@@ -1049,6 +1065,7 @@ func TestConfigurationValuesFrom(t *testing.T) {
 				EventRecorder: recorder,
 				MutationReconciler: MutationReconcileLooper{
 					Client:         client,
+					APIReader:      client,
 					DynamicClient:  dynClient,
 					Scheme:         env.scheme,
 					OCMClient:      fakeOcm,
@@ -1214,6 +1231,7 @@ func TestPatchStrategicMergeWithGitRepositorySource(t *testing.T) {
 		EventRecorder: recorder,
 		MutationReconciler: MutationReconcileLooper{
 			Client:         client,
+			APIReader:      client,
 			DynamicClient:  dynClient,
 			Scheme:         env.scheme,
 			OCMClient:      fakeOcm,
@@ -1245,6 +1263,11 @@ func TestPatchStrategicMergeWithGitRepositorySource(t *testing.T) {
 
 	t.Log("verifying that the strategic merge was performed")
 	args := cache.PushDataCallingArgumentsOnCall(0)
+	assert.Equal(t, ocmcache.SnapshotArtifactType, args.ArtifactType)
+	assert.Equal(t, ocmcache.TarContentMediaType, args.MediaType)
+	assert.Equal(t, "Configuration/"+configuration.Namespace+"/"+configuration.Name, args.Annotations[ocmcache.AnnotationOwner])
+	assert.NotEmpty(t, args.Annotations[ocmcache.AnnotationSourceDigest])
+	assert.NotEmpty(t, args.Annotations[ocmcache.AnnotationConfigDigest])
 	data := args.Content
 	sourceFile := extractFileFromTarGz(t, io.NopCloser(bytes.NewBuffer([]byte(data))), "merge-target.yaml")
 	deployment := appsv1.Deployment{}
@@ -1605,6 +1628,7 @@ func TestPatchStrategicMergeWithResourceSource(t *testing.T) {
 				EventRecorder: recorder,
 				MutationReconciler: MutationReconcileLooper{
 					Client:         client,
+					APIReader:      client,
 					DynamicClient:  dynClient,
 					Scheme:         env.scheme,
 					OCMClient:      fakeOcm,
@@ -1659,6 +1683,589 @@ func TestPatchStrategicMergeWithResourceSource(t *testing.T) {
 	}
 }
 
+func TestConfigurationJSON6902AndYQStrategies(t *testing.T) {
+	testcases := []struct {
+		name   string
+		mutate func(spec *v1alpha1.MutationSpec)
+	}{
+		{
+			name: "should apply json6902 patches",
+			mutate: func(spec *v1alpha1.MutationSpec) {
+				spec.PatchJSON6902 = []v1alpha1.JSON6902Patch{
+					{
+						Path: "merge-target/*.yaml",
+						JSON6902Patch: kustomize.JSON6902Patch{
+							Target: kustomize.Selector{
+								Kind: "Deployment",
+								Name: "test",
+							},
+							Patch: []kustomize.JSON6902{
+								{
+									Op:    "replace",
+									Path:  "/spec/replicas",
+									Value: &apiextensionsv1.JSON{Raw: []byte("3")},
+								},
+								{
+									Op:   "remove",
+									Path: "/spec/template/spec/containers/0/imagePullPolicy",
+								},
+							},
+						},
+					},
+				}
+			},
+		},
+		{
+			name: "should apply yq expressions",
+			mutate: func(spec *v1alpha1.MutationSpec) {
+				spec.YQ = []v1alpha1.YQExpression{
+					{
+						Path:       "merge-target/merge-target.yaml",
+						Expression: ".spec.replicas = 3",
+					},
+					{
+						Path:       "merge-target/*.yaml",
+						Expression: "del(.spec.template.spec.containers[0].imagePullPolicy)",
+					},
+				}
+			},
+		},
+	}
+
+	for i, tt := range testcases {
+		t.Run(fmt.Sprintf("%d: %s", i, tt.name), func(t *testing.T) {
+			cv := DefaultComponent.DeepCopy()
+			conditions.MarkTrue(cv, meta.ReadyCondition, meta.SucceededReason, "test")
+			cd := DefaultComponentDescriptor.DeepCopy()
+
+			resource := DefaultResource.DeepCopy()
+			name := "test-snapshot"
+			snapshot := &v1alpha1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cv.Namespace,
+				},
+				Spec: v1alpha1.SnapshotSpec{
+					Identity: ocmmetav1.Identity{
+						v1alpha1.ComponentNameKey:    cv.Spec.Component,
+						v1alpha1.ComponentVersionKey: cv.Spec.Version.Semver,
+						v1alpha1.ResourceNameKey:     resource.Spec.SourceRef.ResourceRef.Name,
+						v1alpha1.ResourceVersionKey:  resource.Spec.SourceRef.ResourceRef.Version,
+					},
+				},
+			}
+			conditions.MarkTrue(snapshot, meta.ReadyCondition, meta.SucceededReason, "test")
+			resource.Status.SnapshotName = name
+			conditions.MarkTrue(resource, meta.ReadyCondition, meta.SucceededReason, "test")
+
+			configuration := DefaultConfiguration.DeepCopy()
+			configuration.Spec.SourceRef = v1alpha1.ObjectReference{
+				NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+					APIVersion: v1alpha1.GroupVersion.String(),
+					Kind:       "Resource",
+					Name:       resource.Name,
+					Namespace:  resource.Namespace,
+				},
+			}
+			configuration.Spec.ConfigRef = nil
+			configuration.Status.SnapshotName = "configuration-snapshot"
+			tt.mutate(&configuration.Spec)
+
+			objs := []client.Object{cv, cd, resource, snapshot, configuration}
+			client := env.FakeKubeClient(WithObjects(objs...))
+			dynClient := env.FakeDynamicKubeClient(WithObjects(objs...))
+			cache := &cachefakes.FakeCache{}
+			content, err := os.Open(filepath.Join("testdata", "merge-target.tar.gz"))
+			require.NoError(t, err)
+			cache.FetchDataByDigestReturns(content, nil)
+			recorder := record.NewFakeRecorder(32)
+
+			cr := ConfigurationReconciler{
+				Client:        client,
+				DynamicClient: dynClient,
+				Scheme:        env.scheme,
+				EventRecorder: recorder,
+				MutationReconciler: MutationReconcileLooper{
+					Client:         client,
+					APIReader:      client,
+					DynamicClient:  dynClient,
+					Scheme:         env.scheme,
+					OCMClient:      &fakes.MockFetcher{},
+					Cache:          cache,
+					SnapshotWriter: ocmsnapshot.NewOCIWriter(client, cache, env.scheme),
+				},
+			}
+
+			_, err = cr.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: configuration.Namespace,
+					Name:      configuration.Name,
+				},
+			})
+			require.NoError(t, err)
+
+			t.Log("verifying that the strategy has been applied")
+			args := cache.PushDataCallingArgumentsOnCall(0)
+			assert.NotEmpty(t, args.Annotations[ocmcache.AnnotationConfigDigest])
+			sourceFile := extractFileFromTarGz(t, io.NopCloser(bytes.NewBuffer([]byte(args.Content))), "merge-target.yaml")
+			deployment := appsv1.Deployment{}
+			require.NoError(t, yaml.Unmarshal(sourceFile, &deployment))
+			assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+			assert.Empty(t, deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy)
+
+			t.Log("verifying that the strategy is recorded in the snapshot identity")
+			snapshotOutput := &v1alpha1.Snapshot{}
+			require.NoError(t, client.Get(context.Background(), types.NamespacedName{
+				Namespace: configuration.Namespace,
+				Name:      configuration.Status.SnapshotName,
+			}, snapshotOutput))
+			assert.Equal(t, configuration.Name, snapshotOutput.Spec.Identity[v1alpha1.SourceNameKey])
+			assert.Equal(t,
+				args.Annotations[ocmcache.AnnotationConfigDigest],
+				snapshotOutput.Spec.Identity[v1alpha1.SourceArtifactChecksumKey],
+			)
+		})
+	}
+}
+
+func TestConfigurationKustomizeOverlay(t *testing.T) {
+	cv := DefaultComponent.DeepCopy()
+	conditions.MarkTrue(cv, meta.ReadyCondition, meta.SucceededReason, "test")
+	cd := DefaultComponentDescriptor.DeepCopy()
+
+	resource := DefaultResource.DeepCopy()
+	overlayResource := DefaultResource.DeepCopy()
+	overlayResource.Name = "overlay-test-resource"
+
+	snapshot := &v1alpha1.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: cv.Namespace,
+		},
+		Spec: v1alpha1.SnapshotSpec{
+			Identity: ocmmetav1.Identity{
+				v1alpha1.ComponentNameKey:    cv.Spec.Component,
+				v1alpha1.ComponentVersionKey: cv.Spec.Version.Semver,
+				v1alpha1.ResourceNameKey:     resource.Spec.SourceRef.ResourceRef.Name,
+				v1alpha1.ResourceVersionKey:  resource.Spec.SourceRef.ResourceRef.Version,
+			},
+		},
+	}
+	overlaySnapshot := &v1alpha1.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "overlay-test-snapshot",
+			Namespace: cv.Namespace,
+		},
+		Spec: v1alpha1.SnapshotSpec{
+			Identity: ocmmetav1.Identity{
+				v1alpha1.ComponentNameKey:    cv.Spec.Component,
+				v1alpha1.ComponentVersionKey: cv.Spec.Version.Semver,
+				v1alpha1.ResourceNameKey:     "overlay",
+				v1alpha1.ResourceVersionKey:  resource.Spec.SourceRef.ResourceRef.Version,
+			},
+		},
+	}
+	conditions.MarkTrue(snapshot, meta.ReadyCondition, meta.SucceededReason, "test")
+	conditions.MarkTrue(overlaySnapshot, meta.ReadyCondition, meta.SucceededReason, "test")
+	resource.Status.SnapshotName = snapshot.Name
+	overlayResource.Status.SnapshotName = overlaySnapshot.Name
+	conditions.MarkTrue(resource, meta.ReadyCondition, meta.SucceededReason, "test")
+	conditions.MarkTrue(overlayResource, meta.ReadyCondition, meta.SucceededReason, "test")
+
+	configuration := DefaultConfiguration.DeepCopy()
+	configuration.Spec.SourceRef = v1alpha1.ObjectReference{
+		NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "Resource",
+			Name:       resource.Name,
+			Namespace:  resource.Namespace,
+		},
+	}
+	configuration.Spec.ConfigRef = nil
+	configuration.Status.SnapshotName = "configuration-snapshot"
+	configuration.Spec.Kustomize = &v1alpha1.KustomizeOverlay{
+		SourceRef: meta.NamespacedObjectKindReference{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "Resource",
+			Name:       overlayResource.Name,
+			Namespace:  overlayResource.Namespace,
+		},
+		Path: "overlays/production",
+	}
+
+	objs := []client.Object{cv, cd, resource, overlayResource, snapshot, overlaySnapshot, configuration}
+	client := env.FakeKubeClient(WithObjects(objs...))
+	dynClient := env.FakeDynamicKubeClient(WithObjects(objs...))
+	cache := &cachefakes.FakeCache{}
+	content, err := os.Open(filepath.Join("testdata", "merge-target.tar.gz"))
+	require.NoError(t, err)
+	overlayContent, err := os.Open(filepath.Join("testdata", "kustomize-overlay.tar"))
+	require.NoError(t, err)
+	cache.FetchDataByDigestReturnsOnCall(0, content, nil)
+	cache.FetchDataByDigestReturnsOnCall(1, overlayContent, nil)
+
+	cr := ConfigurationReconciler{
+		Client:        client,
+		DynamicClient: dynClient,
+		Scheme:        env.scheme,
+		EventRecorder: record.NewFakeRecorder(32),
+		MutationReconciler: MutationReconcileLooper{
+			Client:         client,
+			APIReader:      client,
+			DynamicClient:  dynClient,
+			Scheme:         env.scheme,
+			OCMClient:      &fakes.MockFetcher{},
+			Cache:          cache,
+			SnapshotWriter: ocmsnapshot.NewOCIWriter(client, cache, env.scheme),
+		},
+	}
+
+	_, err = cr.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: configuration.Namespace,
+			Name:      configuration.Name,
+		},
+	})
+	require.NoError(t, err)
+
+	t.Log("verifying that the overlay has been rendered")
+	args := cache.PushDataCallingArgumentsOnCall(0)
+	manifests := extractFileFromTarGz(t, io.NopCloser(bytes.NewBuffer([]byte(args.Content))), "manifests.yaml")
+	docs := strings.Split(string(manifests), "\n---\n")
+	require.Len(t, docs, 2)
+
+	deployment := appsv1.Deployment{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[0]), &deployment))
+	assert.Equal(t, "production", deployment.Namespace)
+	assert.Equal(t, int32(5), *deployment.Spec.Replicas)
+	assert.Equal(t, "nginx:1.25", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	configMap := corev1.ConfigMap{}
+	require.NoError(t, yaml.Unmarshal([]byte(docs[1]), &configMap))
+	assert.Equal(t, "production", configMap.Namespace)
+	assert.Equal(t, "production", configMap.Data["mode"])
+
+	snapshotOutput := &v1alpha1.Snapshot{}
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{
+		Namespace: configuration.Namespace,
+		Name:      configuration.Status.SnapshotName,
+	}, snapshotOutput))
+	assert.Equal(t, configuration.Name, snapshotOutput.Spec.Identity[v1alpha1.SourceNameKey])
+	assert.Equal(t, string(configuration.UID), snapshotOutput.Spec.Identity[v1alpha1.MutationObjectUUIDKey])
+
+	// the overlay path is part of the identity
+	overlayIdentity := func(path string) string {
+		overlay := configuration.Spec.Kustomize.DeepCopy()
+		overlay.Path = path
+		identity, err := strategyIdentity(configuration, struct {
+			Overlay        *v1alpha1.KustomizeOverlay `json:"overlay"`
+			SourceChecksum string                     `json:"sourceChecksum"`
+		}{
+			Overlay:        overlay,
+			SourceChecksum: overlaySnapshot.Status.LastReconciledDigest,
+		})
+		require.NoError(t, err)
+
+		return identity[v1alpha1.SourceArtifactChecksumKey]
+	}
+	assert.Equal(t, overlayIdentity("overlays/production"), snapshotOutput.Spec.Identity[v1alpha1.SourceArtifactChecksumKey])
+	assert.NotEqual(t, overlayIdentity("overlays/staging"), snapshotOutput.Spec.Identity[v1alpha1.SourceArtifactChecksumKey])
+}
+
+func TestConfigurationHelmTemplate(t *testing.T) {
+	cv := DefaultComponent.DeepCopy()
+	conditions.MarkTrue(cv, meta.ReadyCondition, meta.SucceededReason, "test")
+	cd := DefaultComponentDescriptor.DeepCopy()
+
+	resource := DefaultResource.DeepCopy()
+	snapshot := &v1alpha1.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: cv.Namespace,
+		},
+		Spec: v1alpha1.SnapshotSpec{
+			Identity: ocmmetav1.Identity{
+				v1alpha1.ComponentNameKey:    cv.Spec.Component,
+				v1alpha1.ComponentVersionKey: cv.Spec.Version.Semver,
+				v1alpha1.ResourceNameKey:     resource.Spec.SourceRef.ResourceRef.Name,
+				v1alpha1.ResourceVersionKey:  resource.Spec.SourceRef.ResourceRef.Version,
+			},
+		},
+	}
+	conditions.MarkTrue(snapshot, meta.ReadyCondition, meta.SucceededReason, "test")
+	resource.Status.SnapshotName = snapshot.Name
+	conditions.MarkTrue(resource, meta.ReadyCondition, meta.SucceededReason, "test")
+
+	configuration := DefaultConfiguration.DeepCopy()
+	configuration.Spec.SourceRef = v1alpha1.ObjectReference{
+		NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "Resource",
+			Name:       resource.Name,
+			Namespace:  resource.Namespace,
+		},
+	}
+	configuration.Spec.ConfigRef = nil
+	configuration.Spec.Values = &apiextensionsv1.JSON{Raw: []byte(`{"replicaCount":3}`)}
+	configuration.Spec.HelmTemplate = &v1alpha1.HelmTemplate{
+		KubeVersion: "1.30.0",
+		APIVersions: []string{"monitoring.coreos.com/v1"},
+	}
+	configuration.Status.SnapshotName = "configuration-snapshot"
+
+	objs := []client.Object{cv, cd, resource, snapshot, configuration}
+	client := env.FakeKubeClient(WithObjects(objs...))
+	dynClient := env.FakeDynamicKubeClient(WithObjects(objs...))
+	cache := &cachefakes.FakeCache{}
+	chart, err := os.ReadFile(filepath.Join("testdata", "podinfo-6.3.5.tgz"))
+	require.NoError(t, err)
+	cache.FetchDataByDigestReturns(io.NopCloser(bytes.NewReader(chart)), nil)
+	renderer := &helmfakes.FakeRenderer{}
+	renderer.RenderReturns([]byte("kind: Deployment\n"), nil)
+
+	cr := ConfigurationReconciler{
+		Client:        client,
+		DynamicClient: dynClient,
+		Scheme:        env.scheme,
+		EventRecorder: record.NewFakeRecorder(32),
+		MutationReconciler: MutationReconcileLooper{
+			Client:         client,
+			APIReader:      client,
+			DynamicClient:  dynClient,
+			Scheme:         env.scheme,
+			OCMClient:      &fakes.MockFetcher{},
+			Cache:          cache,
+			SnapshotWriter: ocmsnapshot.NewOCIWriter(client, cache, env.scheme),
+			ChartRenderer:  renderer,
+		},
+	}
+
+	_, err = cr.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: configuration.Namespace,
+			Name:      configuration.Name,
+		},
+	})
+	require.NoError(t, err)
+
+	t.Log("verifying that the chart has been rendered with the configured values")
+	renderArgs := renderer.RenderCallingArgumentsOnCall(0)
+	assert.Equal(t, map[string]any{"replicaCount": float64(3)}, renderArgs.Values)
+	assert.Equal(t, helm.Options{
+		ReleaseName: configuration.Name,
+		Namespace:   configuration.Namespace,
+		KubeVersion: "1.30.0",
+		APIVersions: []string{"monitoring.coreos.com/v1"},
+	}, renderArgs.Options)
+
+	args := cache.PushDataCallingArgumentsOnCall(0)
+	manifests := extractFileFromTarGz(t, io.NopCloser(bytes.NewBuffer([]byte(args.Content))), "manifests.yaml")
+	assert.Equal(t, "kind: Deployment\n", string(manifests))
+	assert.NotEmpty(t, args.Annotations[ocmcache.AnnotationConfigDigest])
+}
+
+func TestConfigurationSecretValuesAreRedacted(t *testing.T) {
+	const password = "s3cr3t-p4ssw0rd"
+
+	cv := DefaultComponent.DeepCopy()
+	conditions.MarkTrue(cv, meta.ReadyCondition, meta.SucceededReason, "test")
+	cd := DefaultComponentDescriptor.DeepCopy()
+
+	resource := DefaultResource.DeepCopy()
+	snapshot := &v1alpha1.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: cv.Namespace,
+		},
+		Spec: v1alpha1.SnapshotSpec{
+			Identity: ocmmetav1.Identity{
+				v1alpha1.ComponentNameKey:    cv.Spec.Component,
+				v1alpha1.ComponentVersionKey: cv.Spec.Version.Semver,
+				v1alpha1.ResourceNameKey:     resource.Spec.SourceRef.ResourceRef.Name,
+				v1alpha1.ResourceVersionKey:  resource.Spec.SourceRef.ResourceRef.Version,
+			},
+		},
+	}
+	conditions.MarkTrue(snapshot, meta.ReadyCondition, meta.SucceededReason, "test")
+	resource.Status.SnapshotName = snapshot.Name
+	conditions.MarkTrue(resource, meta.ReadyCondition, meta.SucceededReason, "test")
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: cv.Namespace,
+		},
+		Data: map[string][]byte{
+			"values.yaml": []byte("database:\n  user: admin\n  password: " + password + "\n"),
+		},
+	}
+
+	configuration := DefaultConfiguration.DeepCopy()
+	configuration.Spec.SourceRef = v1alpha1.ObjectReference{
+		NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "Resource",
+			Name:       resource.Name,
+			Namespace:  resource.Namespace,
+		},
+	}
+	configuration.Spec.ConfigRef = nil
+	configuration.Spec.Values = nil
+	configuration.Spec.ValuesFrom = []v1alpha1.ValuesSource{
+		{
+			SecretSource: &v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: secret.Name},
+				Key:       "values.yaml",
+				SubPath:   "database",
+			},
+		},
+	}
+	configuration.Spec.HelmTemplate = &v1alpha1.HelmTemplate{}
+	configuration.Status.SnapshotName = "configuration-snapshot"
+
+	objs := []client.Object{cv, cd, resource, snapshot, secret, configuration}
+	client := env.FakeKubeClient(WithObjects(objs...))
+	dynClient := env.FakeDynamicKubeClient(WithObjects(objs...))
+	cache := &cachefakes.FakeCache{}
+	chart, err := os.ReadFile(filepath.Join("testdata", "podinfo-6.3.5.tgz"))
+	require.NoError(t, err)
+	cache.FetchDataByDigestReturns(io.NopCloser(bytes.NewReader(chart)), nil)
+	renderer := &helmfakes.FakeRenderer{}
+	renderer.RenderReturns(nil, fmt.Errorf("template: password %q is too weak", password))
+	recorder := &record.FakeRecorder{
+		Events:        make(chan string, 32),
+		IncludeObject: true,
+	}
+
+	cr := ConfigurationReconciler{
+		Client:        client,
+		DynamicClient: dynClient,
+		Scheme:        env.scheme,
+		EventRecorder: recorder,
+		MutationReconciler: MutationReconcileLooper{
+			Client:         client,
+			APIReader:      client,
+			DynamicClient:  dynClient,
+			Scheme:         env.scheme,
+			OCMClient:      &fakes.MockFetcher{},
+			Cache:          cache,
+			SnapshotWriter: ocmsnapshot.NewOCIWriter(client, cache, env.scheme),
+			ChartRenderer:  renderer,
+		},
+	}
+
+	_, err = cr.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: configuration.Namespace,
+			Name:      configuration.Name,
+		},
+	})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), password)
+
+	t.Log("verifying that the chart has been rendered with the secret values")
+	renderArgs := renderer.RenderCallingArgumentsOnCall(0)
+	assert.Equal(t, map[string]any{"user": "admin", "password": password}, renderArgs.Values)
+
+	t.Log("verifying that the secret values are redacted from the status and events")
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{
+		Namespace: configuration.Namespace,
+		Name:      configuration.Name,
+	}, configuration))
+	message := conditions.GetMessage(configuration, meta.ReadyCondition)
+	assert.Contains(t, message, redacted)
+	assert.NotContains(t, message, password)
+
+	close(recorder.Events)
+	for event := range recorder.Events {
+		assert.NotContains(t, event, password)
+	}
+}
+
+func TestConfigurationValuesSourceWatches(t *testing.T) {
+	const (
+		configMapKey = ".metadata.valuesConfigMap"
+		secretKey    = ".metadata.valuesSecret"
+		grantKey     = ".metadata.valuesSourceNamespace"
+	)
+
+	newConfiguration := func(name string, sources ...v1alpha1.ValuesSource) *v1alpha1.Configuration {
+		configuration := DefaultConfiguration.DeepCopy()
+		configuration.Name = name
+		configuration.Spec.ValuesFrom = sources
+
+		return configuration
+	}
+
+	configMapSource := v1alpha1.ValuesSource{
+		ConfigMapSource: &v1alpha1.ConfigMapSource{
+			SourceRef: meta.LocalObjectReference{Name: "values"},
+			Key:       "values.yaml",
+		},
+	}
+	secretSource := v1alpha1.ValuesSource{
+		SecretSource: &v1alpha1.SecretSource{
+			SourceRef: meta.LocalObjectReference{Name: "values"},
+			Key:       "values.yaml",
+		},
+	}
+
+	client := fake.NewClientBuilder().
+		WithScheme(env.scheme).
+		WithObjects(
+			newConfiguration("both", configMapSource, secretSource),
+			newConfiguration("configmap", configMapSource),
+			newConfiguration("secret", secretSource),
+			newConfiguration("inline"),
+			newConfiguration("shared", v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "environment"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			}),
+		).
+		WithIndex(&v1alpha1.Configuration{}, configMapKey, indexValuesSources(valuesConfigMapRef)).
+		WithIndex(&v1alpha1.Configuration{}, secretKey, indexValuesSources(valuesSecretRef)).
+		WithIndex(&v1alpha1.Configuration{}, grantKey, indexValuesSourceNamespaces).
+		Build()
+	cr := ConfigurationReconciler{
+		Client: client,
+	}
+
+	t.Log("verifying that only the metadata of the values sources is watched")
+	configMap := valuesSourceMetadata("ConfigMap")
+	configMap.ObjectMeta = metav1.ObjectMeta{Name: "values", Namespace: DefaultConfiguration.Namespace}
+	assert.Equal(t, corev1.SchemeGroupVersion.WithKind("ConfigMap"), configMap.GroupVersionKind())
+	secret := valuesSourceMetadata("Secret")
+	secret.ObjectMeta = metav1.ObjectMeta{Name: "values", Namespace: DefaultConfiguration.Namespace}
+	assert.Equal(t, corev1.SchemeGroupVersion.WithKind("Secret"), secret.GroupVersionKind())
+
+	names := func(requests []reconcile.Request) []string {
+		var result []string
+		for _, request := range requests {
+			result = append(result, request.Name)
+		}
+
+		return result
+	}
+
+	t.Log("verifying that the configurations referencing the changed object are enqueued")
+	assert.ElementsMatch(t, []string{"both", "configmap"}, names(cr.findObjectsForSource(configMapKey)(context.Background(), configMap)))
+	assert.ElementsMatch(t, []string{"both", "secret"}, names(cr.findObjectsForSource(secretKey)(context.Background(), secret)))
+	shared := valuesSourceMetadata("ConfigMap")
+	shared.ObjectMeta = metav1.ObjectMeta{Name: "environment", Namespace: "platform"}
+	assert.Equal(t, []string{"shared"}, names(cr.findObjectsForSource(configMapKey)(context.Background(), shared)))
+
+	t.Log("verifying that the configurations reading values sources of the namespace of a grant are enqueued")
+	grant := &v1alpha1.ValuesSourceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "environments", Namespace: "platform"},
+	}
+	assert.Equal(t, []string{"shared"}, names(cr.findObjectsForGrant(grantKey)(context.Background(), grant)))
+	grant.Namespace = DefaultConfiguration.Namespace
+	assert.Empty(t, cr.findObjectsForGrant(grantKey)(context.Background(), grant))
+}
+
 func createGitRepository(name, namespace, artifactURL, checksum string) *sourcev1.GitRepository {
 	updatedTime := time.Now()
 	return &sourcev1.GitRepository{
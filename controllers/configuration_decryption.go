@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	sopsage "github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	sopsconfig "github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/keyservice"
+	yamlv3 "gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// ageKeySuffix is the suffix of the keys of the decryption Secret holding age identities.
+const ageKeySuffix = ".agekey"
+
+// decryptor decrypts SOPS encrypted files with the age identities of the decryption Secret. A nil
+// decryptor leaves all files as they are.
+type decryptor struct {
+	identities sopsage.ParsedIdentities
+}
+
+// getDecryptor returns the decryptor of the Decryption, nil if no decryption is configured.
+func (m *MutationReconcileLooper) getDecryptor(ctx context.Context, namespace string, decryption *v1alpha1.Decryption) (*decryptor, error) {
+	if decryption == nil {
+		return nil, nil
+	}
+
+	if decryption.Provider != v1alpha1.SOPSProvider {
+		return nil, fmt.Errorf("unsupported decryption provider %s", decryption.Provider)
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{
+		Name:      decryption.SecretRef.Name,
+		Namespace: namespace,
+	}
+	if err := m.APIReader.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get decryption secret: %w", err)
+	}
+
+	names := make([]string, 0, len(secret.Data))
+	for name := range secret.Data {
+		if strings.HasSuffix(name, ageKeySuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	d := &decryptor{}
+	for _, name := range names {
+		// the parsing error may quote the identities, so it isn't wrapped
+		if err := d.identities.Import(string(secret.Data[name])); err != nil {
+			return nil, fmt.Errorf("failed to parse age identities of key %s in secret %s", name, secret.Name)
+		}
+	}
+
+	if len(d.identities) == 0 {
+		return nil, fmt.Errorf("no age identities found in the %s keys of secret %s", ageKeySuffix, secret.Name)
+	}
+
+	return d, nil
+}
+
+// decrypt returns data decrypted if it's encrypted by SOPS and reports whether it has been decrypted.
+// The data key is decrypted with the age identities of the decryption, honouring the key groups of
+// the file, and the decrypted values are verified against the MAC of the file.
+func (d *decryptor) decrypt(data []byte) ([]byte, bool, error) {
+	if d == nil || !isEncrypted(data) {
+		return data, false, nil
+	}
+
+	format := formats.Yaml
+	if json.Valid(data) {
+		format = formats.Json
+	}
+
+	store := common.StoreForFormat(format, sopsconfig.NewStoresConfig())
+
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load encrypted file: %w", err)
+	}
+
+	if _, err := common.DecryptTree(common.DecryptTreeOpts{
+		Tree:            &tree,
+		KeyServices:     []keyservice.KeyServiceClient{keyservice.NewCustomLocalClient(ageKeyServer{identities: d.identities})},
+		DecryptionOrder: sops.DefaultDecryptionOrder,
+		Cipher:          aes.NewCipher(),
+	}); err != nil {
+		return nil, false, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	decrypted, err := store.EmitPlainFile(tree.Branches)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode decrypted file: %w", err)
+	}
+
+	return decrypted, true, nil
+}
+
+// isEncrypted reports whether data is a YAML or JSON file encrypted by SOPS, which holds the SOPS
+// metadata in its first document.
+func isEncrypted(data []byte) bool {
+	var doc struct {
+		Sops *struct {
+			MAC     string `yaml:"mac"`
+			Version string `yaml:"version"`
+		} `yaml:"sops"`
+	}
+	if err := yamlv3.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return false
+	}
+
+	return doc.Sops != nil && doc.Sops.MAC != "" && doc.Sops.Version != ""
+}
+
+// ageKeyServer is a SOPS key service decrypting the data keys encrypted for age recipients with the
+// identities of the decryption Secret. Unlike the default key service of SOPS it never reads keys from
+// the environment of the controller, and it doesn't decrypt data keys of other providers.
+type ageKeyServer struct {
+	identities sopsage.ParsedIdentities
+}
+
+// Encrypt isn't supported, the controller only decrypts.
+func (ageKeyServer) Encrypt(context.Context, *keyservice.EncryptRequest) (*keyservice.EncryptResponse, error) {
+	return nil, errors.New("encryption isn't supported")
+}
+
+// Decrypt decrypts the data key of the request if it's encrypted for an age recipient.
+func (s ageKeyServer) Decrypt(_ context.Context, req *keyservice.DecryptRequest) (*keyservice.DecryptResponse, error) {
+	ageKey := req.GetKey().GetAgeKey()
+	if ageKey == nil {
+		return nil, errors.New("only age keys are supported")
+	}
+
+	// without identities SOPS would fall back to the identities of the environment
+	if len(s.identities) == 0 {
+		return nil, errors.New("no age identities")
+	}
+
+	key := &sopsage.MasterKey{
+		Recipient:    ageKey.GetRecipient(),
+		EncryptedKey: string(req.GetCiphertext()),
+	}
+	s.identities.ApplyToMasterKey(key)
+
+	plaintext, err := key.Decrypt()
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyservice.DecryptResponse{Plaintext: plaintext}, nil
+}
+
+// decryptConfigData decrypts the config data if it's encrypted by SOPS and returns it together with
+// the values which have been decrypted, which must be redacted like the values of Secrets.
+func decryptConfigData(d *decryptor, configData []byte) ([]byte, secretValues, error) {
+	configObj, decrypted, err := d.decrypt(configData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt config data: %w", err)
+	}
+
+	if !decrypted {
+		return configObj, nil, nil
+	}
+
+	secrets, err := collectEncryptedValues(configData, configObj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read decrypted config data: %w", err)
+	}
+
+	return configObj, secrets, nil
+}
+
+// decryptFile decrypts the file at path in place if it's encrypted by SOPS and reports whether it has
+// been decrypted together with the values which have been decrypted, which must be redacted like the
+// values of Secrets. The file must be located in a temporary directory of the mutation.
+func (d *decryptor) decryptFile(path string) (bool, secretValues, error) {
+	if d == nil {
+		return false, nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, nil, err
+	}
+
+	decrypted, ok, err := d.decrypt(data)
+	if err != nil || !ok {
+		return false, nil, err
+	}
+
+	secrets, err := collectEncryptedValues(data, decrypted)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to read decrypted file: %w", err)
+	}
+
+	return true, secrets, os.WriteFile(path, decrypted, FSOwnerReadWrite)
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// checkValuesSourceGrant returns an error unless the ConfigMap or Secret grants the namespace access
+// to it as values source. Objects of the same namespace are always accessible. Objects of other
+// namespaces grant access with the values source grant annotation or a ValuesSourceGrant of their namespace.
+func (m *MutationReconcileLooper) checkValuesSourceGrant(ctx context.Context, kind string, obj metav1.Object, namespace string) error {
+	if obj.GetNamespace() == namespace {
+		return nil
+	}
+
+	if annotation, ok := obj.GetAnnotations()[v1alpha1.ValuesSourceGrantAnnotation]; ok {
+		if grantsNamespace(strings.Split(annotation, ","), namespace) {
+			return nil
+		}
+	}
+
+	grants := &v1alpha1.ValuesSourceGrantList{}
+	if err := m.Client.List(ctx, grants, client.InNamespace(obj.GetNamespace())); err != nil {
+		return fmt.Errorf("failed to list values source grants: %w", err)
+	}
+
+	for _, grant := range grants.Items {
+		if !grantsNamespace(grant.Spec.From, namespace) {
+			continue
+		}
+
+		for _, to := range grant.Spec.To {
+			if to.Kind == kind && (to.Name == "" || to.Name == obj.GetName()) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%s %s/%s doesn't grant namespace %s access to it as values source", kind, obj.GetNamespace(), obj.GetName(), namespace)
+}
+
+func grantsNamespace(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns = strings.TrimSpace(ns); ns == "*" || ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
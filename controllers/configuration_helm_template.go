@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/helm"
+)
+
+// mutateHelmTemplate renders the Helm chart held by the source data and returns the directory holding
+// the rendered manifests together with the identity of the snapshot and the secret values of the values.
+func (m *MutationReconcileLooper) mutateHelmTemplate(
+	ctx context.Context,
+	obj v1alpha1.MutationObject,
+	mutationSpec *v1alpha1.MutationSpec,
+	sourceData []byte,
+) (_ string, _ ocmmetav1.Identity, _ secretValues, err error) {
+	if m.ChartRenderer == nil {
+		return "", nil, nil, errors.New("helm chart rendering is not configured")
+	}
+
+	// the rendering errors may quote the values
+	var secrets secretValues
+	defer func() {
+		err = secrets.redactError(err)
+	}()
+
+	values := map[string]any{}
+	if mutationSpec.Values != nil || len(mutationSpec.ValuesFrom) > 0 {
+		raw, rawSecrets, err := m.getValues(ctx, obj, mutationSpec)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		secrets = rawSecrets
+
+		if err := json.Unmarshal(raw.Raw, &values); err != nil {
+			return "", nil, nil, fmt.Errorf("failed to unmarshal values: %w", err)
+		}
+	}
+
+	template := mutationSpec.HelmTemplate
+	opts := helm.Options{
+		ReleaseName: obj.GetName(),
+		Namespace:   obj.GetNamespace(),
+		KubeVersion: template.KubeVersion,
+		APIVersions: template.APIVersions,
+		IncludeCRDs: template.IncludeCRDs,
+	}
+
+	if template.ReleaseName != "" {
+		opts.ReleaseName = template.ReleaseName
+	}
+
+	if template.Namespace != "" {
+		opts.Namespace = template.Namespace
+	}
+
+	manifests, err := m.ChartRenderer.Render(ctx, sourceData, values, opts)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// It will be removed once it has been tarred. The rendered manifests may hold secret values, so
+	// it's removed right away if the mutation fails.
+	outputDir, err := os.MkdirTemp("", "helm-template-")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("tmp dir error: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(outputDir)
+		}
+	}()
+
+	if err := os.WriteFile(filepath.Join(outputDir, renderedManifestsFile), manifests, FSOwnerReadWrite); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to write rendered manifests: %w", err)
+	}
+
+	identity, err := strategyIdentity(obj, struct {
+		Options helm.Options   `json:"options"`
+		Values  map[string]any `json:"values"`
+	}{
+		Options: opts,
+		Values:  values,
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return outputDir, identity, secrets, nil
+}
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fluxcd/pkg/apis/kustomize"
+	generator "github.com/fluxcd/pkg/kustomize"
+	"github.com/fluxcd/pkg/tar"
+	"github.com/opencontainers/go-digest"
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// kustomizeResourceFile is the name under which a selected file is passed to kustomize.
+const kustomizeResourceFile = "resource.yaml"
+
+// mutateJSON6902 applies the JSON6902 patches of the mutation spec to the source data and returns the
+// directory holding the result together with the identity of the snapshot.
+func (m *MutationReconcileLooper) mutateJSON6902(
+	_ context.Context,
+	obj v1alpha1.MutationObject,
+	mutationSpec *v1alpha1.MutationSpec,
+	sourceData []byte,
+) (_ string, _ ocmmetav1.Identity, err error) {
+	workDir, err := extractSourceData(sourceData, "json6902-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(workDir)
+		}
+	}()
+
+	for _, patch := range mutationSpec.PatchJSON6902 {
+		files, err := selectFiles(workDir, patch.Path)
+		if err != nil {
+			return "", nil, err
+		}
+
+		for _, file := range files {
+			if err := applyJSON6902Patch(file, patch.JSON6902Patch); err != nil {
+				return "", nil, fmt.Errorf("failed to patch %s: %w", file, err)
+			}
+		}
+	}
+
+	identity, err := strategyIdentity(obj, mutationSpec.PatchJSON6902)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return workDir, identity, nil
+}
+
+// applyJSON6902Patch applies the patch to the objects in file selected by the patch target.
+// Documents which aren't Kubernetes objects, e.g. values files, are left untouched and the file
+// isn't rewritten if it doesn't hold any object.
+func applyJSON6902Patch(file string, patch kustomize.JSON6902Patch) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := kio.FromBytes(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse yaml: %w", err)
+	}
+
+	operations, err := yaml.Marshal(patch.Patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch operations: %w", err)
+	}
+
+	objects := 0
+	for i, node := range nodes {
+		if node.YNode().Kind != kyaml.MappingNode || node.GetApiVersion() == "" || node.GetKind() == "" {
+			continue
+		}
+
+		object, err := node.String()
+		if err != nil {
+			return fmt.Errorf("failed to serialize document: %w", err)
+		}
+
+		patched, err := patchObject([]byte(object), string(operations), patch.Target)
+		if err != nil {
+			return err
+		}
+
+		result, err := kio.FromBytes(patched)
+		if err != nil {
+			return fmt.Errorf("failed to parse patched object: %w", err)
+		}
+
+		if len(result) != 1 {
+			return fmt.Errorf("patching %s %s resulted in %d objects", node.GetKind(), node.GetName(), len(result))
+		}
+
+		nodes[i] = result[0]
+		objects++
+	}
+
+	if objects == 0 {
+		return nil
+	}
+
+	contents, err := kio.StringAll(nodes)
+	if err != nil {
+		return fmt.Errorf("failed to serialize patched documents: %w", err)
+	}
+
+	return os.WriteFile(file, []byte(contents), FSOwnerReadWrite)
+}
+
+// patchObject applies the patch operations to the object if it's selected by the target. The object
+// is built by kustomize in a separate directory, so kustomization files which are part of the source
+// are left untouched.
+func patchObject(object []byte, operations string, target kustomize.Selector) ([]byte, error) {
+	buildDir, err := os.MkdirTemp("", "json6902-build-")
+	if err != nil {
+		return nil, fmt.Errorf("tmp dir error: %w", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	if err := os.WriteFile(filepath.Join(buildDir, kustomizeResourceFile), object, FSOwnerReadWrite); err != nil {
+		return nil, err
+	}
+
+	kus := kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+		Resources: []string{
+			kustomizeResourceFile,
+		},
+		Patches: []kustypes.Patch{
+			{
+				Patch:  operations,
+				Target: adaptSelector(target),
+			},
+		},
+	}
+
+	manifest, err := yaml.Marshal(kus)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(buildDir, "kustomization.yaml"), manifest, FSOwnerReadWrite); err != nil {
+		return nil, err
+	}
+
+	result, err := generator.SecureBuild(buildDir, buildDir, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.AsYaml()
+}
+
+// adaptSelector converts a Flux selector into the selector used by kustomize.
+func adaptSelector(selector kustomize.Selector) *kustypes.Selector {
+	return &kustypes.Selector{
+		ResId: resid.ResId{
+			Gvk: resid.Gvk{
+				Group:   selector.Group,
+				Version: selector.Version,
+				Kind:    selector.Kind,
+			},
+			Name:      selector.Name,
+			Namespace: selector.Namespace,
+		},
+		AnnotationSelector: selector.AnnotationSelector,
+		LabelSelector:      selector.LabelSelector,
+	}
+}
+
+// extractSourceData extracts the tar archive holding the source into a new temporary directory.
+// The directory is removed once the snapshot has been written.
+func extractSourceData(sourceData []byte, pattern string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("tmp dir error: %w", err)
+	}
+
+	if err := untarSourceData(sourceData, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+
+		return "", err
+	}
+
+	return tmpDir, nil
+}
+
+// untarSourceData extracts the tar archive holding the source into dir.
+func untarSourceData(sourceData []byte, dir string) error {
+	gzipSnapshot := &bytes.Buffer{}
+	gz := gzip.NewWriter(gzipSnapshot)
+	if _, err := gz.Write(sourceData); err != nil {
+		gz.Close()
+
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if err := tar.Untar(gzipSnapshot, dir); err != nil {
+		return fmt.Errorf("failed to untar source data: %w", err)
+	}
+
+	return nil
+}
+
+// selectFiles returns the regular files in dir whose path relative to dir matches pattern.
+func selectFiles(dir, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		// the pattern has been validated above
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to select files: %w", err)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found matching path %q", pattern)
+	}
+
+	return files, nil
+}
+
+// strategyIdentity returns the snapshot identity for a strategy configured inline on the mutation object.
+// The checksum of the strategy takes the place of the patch source checksum used by mutatePatchStrategicMerge.
+func strategyIdentity(obj v1alpha1.MutationObject, strategy any) (ocmmetav1.Identity, error) {
+	data, err := json.Marshal(strategy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mutation strategy: %w", err)
+	}
+
+	return ocmmetav1.Identity{
+		v1alpha1.SourceNameKey:             obj.GetName(),
+		v1alpha1.SourceNamespaceKey:        obj.GetNamespace(),
+		v1alpha1.MutationObjectUUIDKey:     string(obj.GetUID()),
+		v1alpha1.SourceArtifactChecksumKey: digest.FromBytes(data).String(),
+	}, nil
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	generator "github.com/fluxcd/pkg/kustomize"
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+	"sigs.k8s.io/kustomize/api/konfig"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// renderedManifestsFile is the file in the snapshot holding manifests rendered by kustomize or Helm.
+const renderedManifestsFile = "manifests.yaml"
+
+// mutateKustomize renders the source data with the kustomize overlay of the mutation spec and returns
+// the directory holding the rendered manifests together with the identity of the snapshot.
+func (m *MutationReconcileLooper) mutateKustomize(
+	ctx context.Context,
+	obj v1alpha1.MutationObject,
+	mutationSpec *v1alpha1.MutationSpec,
+	sourceData []byte,
+) (string, ocmmetav1.Identity, error) {
+	overlay := mutationSpec.Kustomize
+
+	tmpDir, err := os.MkdirTemp("", "kustomize-overlay-")
+	if err != nil {
+		return "", nil, fmt.Errorf("tmp dir error: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	workDir, err := securejoin.SecureJoin(tmpDir, "work")
+	if err != nil {
+		return "", nil, err
+	}
+
+	source, err := m.fetchPatchSource(ctx, obj, overlay.SourceRef, workDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if source == nil {
+		return "", nil, fmt.Errorf("overlay source kind '%s' not supported", overlay.SourceRef.Kind)
+	}
+
+	if err := extractBase(sourceData, workDir, overlay.GetBasePath()); err != nil {
+		return "", nil, err
+	}
+
+	overlayDir, err := securejoin.SecureJoin(workDir, overlay.Path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	result, err := generator.SecureBuild(workDir, overlayDir, false)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build overlay %s: %w", overlay.Path, err)
+	}
+
+	manifests, err := result.AsYaml()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render manifests: %w", err)
+	}
+
+	// the overlay spec is part of the identity, so changing e.g. the overlay path results in a new snapshot.
+	identity, err := strategyIdentity(obj, struct {
+		Overlay        *v1alpha1.KustomizeOverlay `json:"overlay"`
+		SourceChecksum string                     `json:"sourceChecksum"`
+	}{
+		Overlay:        overlay,
+		SourceChecksum: source[v1alpha1.SourceArtifactChecksumKey],
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	// DO NOT Defer remove this, it will be removed once it has been tarred.
+	outputDir, err := os.MkdirTemp("", "kustomize-output-")
+	if err != nil {
+		return "", nil, fmt.Errorf("tmp dir error: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(outputDir, renderedManifestsFile), manifests, FSOwnerReadWrite); err != nil {
+		os.RemoveAll(outputDir)
+
+		return "", nil, fmt.Errorf("failed to write rendered manifests: %w", err)
+	}
+
+	return outputDir, identity, nil
+}
+
+// extractBase replaces the content of basePath within workDir with the source data.
+func extractBase(sourceData []byte, workDir, basePath string) error {
+	baseDir, err := securejoin.SecureJoin(workDir, basePath)
+	if err != nil {
+		return err
+	}
+
+	if baseDir == filepath.Clean(workDir) {
+		return fmt.Errorf("base path %q must not be the root of the overlay source", basePath)
+	}
+
+	if err := os.RemoveAll(baseDir); err != nil {
+		return fmt.Errorf("failed to clear base path: %w", err)
+	}
+
+	if err := untarSourceData(sourceData, baseDir); err != nil {
+		return err
+	}
+
+	return ensureKustomization(baseDir)
+}
+
+// ensureKustomization creates a kustomization file listing all YAML files in dir, unless dir
+// already contains one, so the source can be referenced as a base by the overlay.
+func ensureKustomization(dir string) error {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return nil
+		}
+	}
+
+	var resources []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		resources = append(resources, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list source files: %w", err)
+	}
+
+	kus := kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+		Resources: resources,
+	}
+
+	manifest, err := yaml.Marshal(kus)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, konfig.DefaultKustomizationFileName()), manifest, FSOwnerReadWrite)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+)
+
+// applyProfile overlays the defaults of the config data with the defaults of the named profile and
+// appends the rules of the profile to the rules of the config data.
+func applyProfile(config *configdata.ConfigData, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := config.Configuration.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(config.Configuration.Profiles))
+		for n := range config.Configuration.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("profile %s not found in config data, available profiles: [%s]", name, strings.Join(names, ", "))
+	}
+
+	if config.Configuration.Defaults == nil {
+		config.Configuration.Defaults = make(map[string]any)
+	}
+
+	config.Configuration.Defaults = mergeValues(config.Configuration.Defaults, profile.Defaults)
+	config.Configuration.Rules = append(config.Configuration.Rules, profile.Rules...)
+
+	return nil
+}
+
+// mergedValues returns the values merged over the defaults, which the schema validates.
+func mergedValues(defaults, values []byte) ([]byte, error) {
+	merged := make(map[string]any)
+	for _, doc := range [][]byte{defaults, values} {
+		data := make(map[string]any)
+		if err := json.Unmarshal(doc, &data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal values: %w", err)
+		}
+
+		merged = mergeValues(merged, data)
+	}
+
+	return json.Marshal(merged)
+}
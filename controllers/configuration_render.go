@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ocmruntime "ocm.software/ocm/api/utils/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/component"
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+	"github.com/open-component-model/ocm-controller/pkg/engine"
+)
+
+// renderConfiguration evaluates the configuration program declared by the config data, if any,
+// and writes the files it produces into sourceDir.
+func (m *MutationReconcileLooper) renderConfiguration(
+	ctx context.Context,
+	configObj []byte,
+	values *apiextensionsv1.JSON,
+	mutationSpec *v1alpha1.MutationSpec,
+	sourceDir string,
+) error {
+	config := &configdata.ConfigData{}
+	if err := ocmruntime.DefaultYAMLEncoding.Unmarshal(configObj, config); err != nil {
+		return fmt.Errorf("failed to unmarshal content: %w", err)
+	}
+
+	if config.Configuration.Render == nil {
+		return nil
+	}
+
+	if err := applyProfile(config, mutationSpec.Profile); err != nil {
+		return err
+	}
+
+	defaults, err := json.Marshal(config.Configuration.Defaults)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration defaults: %w", err)
+	}
+
+	doc, err := mergeDefaultsAndConfigValues(defaults, values.Raw)
+	if err != nil {
+		return err
+	}
+
+	merged, err := doc.marshal()
+	if err != nil {
+		return err
+	}
+
+	var mergedValues map[string]any
+	if err := yaml.Unmarshal(merged, &mergedValues); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration values: %w", err)
+	}
+
+	var componentSpec any
+	if mutationSpec.ConfigRef != nil && mutationSpec.ConfigRef.Kind == v1alpha1.ComponentVersionKind {
+		cv, err := m.getComponentVersion(ctx, mutationSpec.ConfigRef)
+		if err != nil {
+			return err
+		}
+
+		cd, err := component.GetComponentDescriptor(ctx, m.Client, nil, cv.Status.ComponentDescriptor)
+		if err != nil {
+			return err
+		}
+
+		if cd == nil {
+			return fmt.Errorf("component descriptor not found with ref: %+v", cv.Status.ComponentDescriptor.ComponentDescriptorRef)
+		}
+
+		componentSpec = cd.Spec
+	}
+
+	files, err := engine.ReadFiles(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	return engine.Render(config.Configuration.Render, sourceDir, engine.Inputs{
+		Values:    mergedValues,
+		Component: componentSpec,
+		Files:     files,
+	})
+}
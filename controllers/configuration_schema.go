@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/opencontainers/go-digest"
+	"github.com/xeipuuv/gojsonschema"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ocmruntime "ocm.software/ocm/api/utils/runtime"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+)
+
+// valuesSchemaKey is the key of the ConfigMap holding the JSON schema of the values.
+const valuesSchemaKey = "schema.json"
+
+// valuesSchemaName returns the name of the ConfigMap publishing the values schema of obj.
+func valuesSchemaName(obj v1alpha1.MutationObject) string {
+	return obj.GetName() + "-values-schema"
+}
+
+// checkValues publishes the schema of the config data and validates the values merged over the
+// defaults against it, including the defaults of the profile if one is selected. The violations are
+// recorded in the status of the object by field path, with the secret values redacted from their messages.
+func (m *MutationReconcileLooper) checkValues(
+	ctx context.Context,
+	obj v1alpha1.MutationObject,
+	configObj []byte,
+	values *apiextensionsv1.JSON,
+	profile string,
+	secrets secretValues,
+) error {
+	config := &configdata.ConfigData{}
+	if err := ocmruntime.DefaultYAMLEncoding.Unmarshal(configObj, config); err != nil {
+		return fmt.Errorf("failed to unmarshal content: %w", err)
+	}
+
+	schema := config.Configuration.Schema
+	if err := m.publishValuesSchema(ctx, obj, schema); err != nil {
+		return err
+	}
+
+	if len(schema) == 0 {
+		return nil
+	}
+
+	if err := applyProfile(config, profile); err != nil {
+		return err
+	}
+
+	defaults, err := json.Marshal(config.Configuration.Defaults)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration defaults: %w", err)
+	}
+
+	document, err := mergedValues(defaults, values.Raw)
+	if err != nil {
+		return err
+	}
+
+	violations, err := validateValues(schema, document)
+	if err != nil {
+		return fmt.Errorf("configurator error: %w", err)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(violations))
+	for i := range violations {
+		violations[i].Message = secrets.redact(violations[i].Message)
+		messages = append(messages, violations[i].Field+": "+violations[i].Message)
+	}
+
+	obj.GetStatus().ValuesValidationErrors = violations
+
+	return fmt.Errorf("configurator error: validation failed: %s", strings.Join(messages, "; "))
+}
+
+// validateValues validates the JSON values against the JSON schema and returns the violations sorted
+// by field path. The violation of a required property is reported at the path of the property.
+func validateValues(schema, values []byte) ([]v1alpha1.ValuesValidationError, error) {
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid values schema: %w", err)
+	}
+
+	result, err := s.Validate(gojsonschema.NewBytesLoader(values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate values: %w", err)
+	}
+
+	var violations []v1alpha1.ValuesValidationError
+	for _, e := range result.Errors() {
+		field := e.Field()
+		if property, ok := e.Details()["property"].(string); ok && e.Type() == "required" {
+			if field == gojsonschema.STRING_CONTEXT_ROOT {
+				field = property
+			} else {
+				field += "." + property
+			}
+		}
+
+		violations = append(violations, v1alpha1.ValuesValidationError{
+			Field:   field,
+			Type:    e.Type(),
+			Message: e.Description(),
+		})
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Field != violations[j].Field {
+			return violations[i].Field < violations[j].Field
+		}
+
+		return violations[i].Message < violations[j].Message
+	})
+
+	return violations, nil
+}
+
+// publishValuesSchema writes the schema into the values schema ConfigMap of obj and references it in
+// the status. The ConfigMap is removed once the config data doesn't declare a schema anymore. A
+// ConfigMap of the same name which isn't owned by obj is neither adopted nor removed.
+func (m *MutationReconcileLooper) publishValuesSchema(ctx context.Context, obj v1alpha1.MutationObject, schema []byte) error {
+	name := valuesSchemaName(obj)
+
+	if len(schema) == 0 {
+		if obj.GetStatus().ValuesSchema == nil {
+			return nil
+		}
+
+		if err := m.deleteConfigMap(ctx, obj, name); err != nil {
+			return fmt.Errorf("failed to delete values schema: %w", err)
+		}
+
+		obj.GetStatus().ValuesSchema = nil
+
+		return nil
+	}
+
+	if err := m.publishConfigMap(ctx, obj, name, map[string]string{
+		valuesSchemaKey: string(schema),
+	}); err != nil {
+		return fmt.Errorf("failed to publish values schema: %w", err)
+	}
+
+	obj.GetStatus().ValuesSchema = &v1alpha1.ValuesSchema{
+		ConfigMapRef: meta.LocalObjectReference{Name: name},
+		Digest:       digest.FromBytes(schema).String(),
+	}
+
+	return nil
+}
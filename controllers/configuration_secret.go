@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// redacted replaces the secret values in messages.
+const redacted = "**REDACTED**"
+
+// sopsEncryptedValue matches the values encrypted by SOPS.
+var sopsEncryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:.*,iv:.+,tag:.+,type:.+\]$`)
+
+// secretValues are the values read from Secrets or decrypted from SOPS encrypted values. They must
+// not be revealed in the events, logs, status messages or errors of an object.
+type secretValues []string
+
+// add adds the value unless it's empty.
+func (s *secretValues) add(value string) {
+	if value != "" {
+		*s = append(*s, value)
+	}
+}
+
+// redact replaces the secret values in msg. A value is replaced where it forms a token of its own, so
+// a value doesn't mangle the words and numbers containing it. The lines still containing a value
+// within a word or a number, which is likely for short values such as PINs or ports, are replaced as
+// a whole, and so is msg if a value spanning several lines remains.
+func (s secretValues) redact(msg string) string {
+	for _, value := range s {
+		msg = redactToken(msg, value)
+	}
+
+	if !s.revealedIn(msg) {
+		return msg
+	}
+
+	lines := strings.SplitAfter(msg, "\n")
+	for i, line := range lines {
+		if s.revealedIn(line) {
+			lines[i] = redacted + line[len(strings.TrimSuffix(line, "\n")):]
+		}
+	}
+
+	if msg = strings.Join(lines, ""); s.revealedIn(msg) {
+		return redacted
+	}
+
+	return msg
+}
+
+// revealedIn reports whether a secret value occurs in msg outside of the redacted values.
+func (s secretValues) revealedIn(msg string) bool {
+	for _, part := range strings.Split(msg, redacted) {
+		for _, value := range s {
+			if strings.Contains(part, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// redactToken replaces the occurrences of value in msg which aren't preceded or followed by a word
+// character continuing the value.
+func redactToken(msg, value string) string {
+	var (
+		out   strings.Builder
+		start int
+		from  int
+	)
+
+	for {
+		i := strings.Index(msg[start:], value)
+		if i < 0 {
+			break
+		}
+
+		i += start
+		end := i + len(value)
+
+		before := i == 0 || !isWordByte(msg[i-1]) || !isWordByte(value[0])
+		after := end == len(msg) || !isWordByte(msg[end]) || !isWordByte(value[len(value)-1])
+		if !before || !after {
+			start = i + 1
+
+			continue
+		}
+
+		out.WriteString(msg[from:i])
+		out.WriteString(redacted)
+		from, start = end, end
+	}
+
+	if from == 0 {
+		return msg
+	}
+
+	out.WriteString(msg[from:])
+
+	return out.String()
+}
+
+// isWordByte reports whether b is part of a word. Bytes of multi-byte characters are considered
+// part of a word.
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// redactError returns err with the secret values replaced in its message. The returned error
+// doesn't wrap err if it had to be redacted, so the original message can't be revealed.
+func (s secretValues) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	if redactedMsg := s.redact(msg); redactedMsg != msg {
+		return errors.New(redactedMsg)
+	}
+
+	return err
+}
+
+// collectSecretValues returns the scalar values of data read from a Secret sorted for redaction.
+func collectSecretValues(data any) secretValues {
+	var values secretValues
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch x := v.(type) {
+		case map[string]any:
+			for _, item := range x {
+				walk(item)
+			}
+		case []any:
+			for _, item := range x {
+				walk(item)
+			}
+		case string:
+			values.add(x)
+		case float64:
+			values.add(strconv.FormatFloat(x, 'f', -1, 64))
+		case bool:
+			values.add(strconv.FormatBool(x))
+		}
+	}
+	walk(data)
+
+	sortSecretValues(values)
+
+	return values
+}
+
+// collectEncryptedValues returns the values of the SOPS encrypted file which have been encrypted
+// sorted for redaction. The values SOPS left unencrypted are read from the decrypted file by their
+// path, so they aren't collected. The decoding errors may quote the content, so they aren't wrapped.
+func collectEncryptedValues(encrypted, decrypted []byte) (secretValues, error) {
+	encryptedDocs, err := decodeDocuments(encrypted)
+	if err != nil {
+		return nil, errors.New("failed to decode encrypted content")
+	}
+
+	decryptedDocs, err := decodeDocuments(decrypted)
+	if err != nil {
+		return nil, errors.New("failed to decode decrypted content")
+	}
+
+	var values secretValues
+
+	var walk func(enc, dec *yamlv3.Node)
+	walk = func(enc, dec *yamlv3.Node) {
+		if enc == nil || enc.Kind != dec.Kind {
+			return
+		}
+
+		switch dec.Kind {
+		case yamlv3.DocumentNode, yamlv3.SequenceNode:
+			for i := range dec.Content {
+				if i < len(enc.Content) {
+					walk(enc.Content[i], dec.Content[i])
+				}
+			}
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(dec.Content); i += 2 {
+				walk(mappingValue(enc, dec.Content[i].Value), dec.Content[i+1])
+			}
+		case yamlv3.ScalarNode:
+			if sopsEncryptedValue.MatchString(enc.Value) {
+				values.add(dec.Value)
+			}
+		}
+	}
+
+	for i, doc := range decryptedDocs {
+		if i < len(encryptedDocs) {
+			walk(encryptedDocs[i], doc)
+		}
+	}
+
+	sortSecretValues(values)
+
+	return values, nil
+}
+
+// merge returns the values of s and other sorted for redaction.
+func (s secretValues) merge(other secretValues) secretValues {
+	if len(other) == 0 {
+		return s
+	}
+
+	merged := append(append(secretValues{}, s...), other...)
+	sortSecretValues(merged)
+
+	return merged
+}
+
+// sortSecretValues sorts the values longest first so that values containing other values are
+// redacted as a whole.
+func sortSecretValues(values secretValues) {
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+
+		return values[i] < values[j]
+	})
+}
+
+func (m *MutationReconcileLooper) fromSecretSource(
+	ctx context.Context,
+	source *v1alpha1.SecretSource,
+	namespace, name string,
+) (map[string]any, secretValues, string, error) {
+	data := make(map[string]any)
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{
+		Name:      source.SourceRef.Name,
+		Namespace: namespace,
+	}
+	if source.Namespace != "" {
+		key.Namespace = source.Namespace
+	}
+	if err := m.APIReader.Get(ctx, key, secret); err != nil {
+		if source.Optional && apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Info("optional secret not found for Configuration", "namespace", namespace, "configuration", name, "secret", key.String())
+
+			return nil, nil, "", nil
+		}
+
+		return nil, nil, "", fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if err := m.checkValuesSourceGrant(ctx, "Secret", secret, namespace); err != nil {
+		return nil, nil, "", err
+	}
+
+	content, found := secret.Data[source.Key]
+	if !found {
+		return nil, nil, "", fmt.Errorf("key %s not found in secret %s", source.Key, source.SourceRef.Name)
+	}
+
+	// the decoding error may quote the content of the secret, so it isn't wrapped
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to unmarshal values of key %s in secret %s", source.Key, source.SourceRef.Name)
+	}
+
+	secrets := collectSecretValues(data)
+
+	if source.SubPath != "" {
+		data, found = extractSubpath(data, source.SubPath)
+		if !found {
+			return nil, nil, "", errors.New("subPath not found")
+		}
+	}
+
+	return data, secrets, secret.ResourceVersion, nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// mutateYQ evaluates the yq expressions of the mutation spec against the source data and returns the
+// directory holding the result together with the identity of the snapshot.
+func (m *MutationReconcileLooper) mutateYQ(
+	_ context.Context,
+	obj v1alpha1.MutationObject,
+	mutationSpec *v1alpha1.MutationSpec,
+	sourceData []byte,
+) (_ string, _ ocmmetav1.Identity, err error) {
+	workDir, err := extractSourceData(sourceData, "yq-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(workDir)
+		}
+	}()
+
+	for _, expression := range mutationSpec.YQ {
+		files, err := selectFiles(workDir, expression.Path)
+		if err != nil {
+			return "", nil, err
+		}
+
+		for _, file := range files {
+			if err := evaluateYQExpression(file, expression.Expression); err != nil {
+				return "", nil, fmt.Errorf("failed to evaluate yq expression on %s: %w", file, err)
+			}
+		}
+	}
+
+	identity, err := strategyIdentity(obj, mutationSpec.YQ)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return workDir, identity, nil
+}
+
+// evaluateYQExpression evaluates expression against every document in file and replaces
+// the content of the file with the result.
+func evaluateYQExpression(file, expression string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	preferences := yqlib.NewDefaultYamlPreferences()
+	result, err := yqlib.NewStringEvaluator().Evaluate(
+		expression,
+		string(content),
+		yqlib.NewYamlEncoder(preferences),
+		yqlib.NewYamlDecoder(preferences),
+	)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, []byte(result), FSOwnerReadWrite)
+}
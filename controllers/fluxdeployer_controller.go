@@ -12,6 +12,7 @@ import (
 	"github.com/open-component-model/ocm-controller/pkg/metrics"
 	"github.com/open-component-model/ocm-controller/pkg/status"
 	mh "github.com/open-component-model/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -38,6 +39,7 @@ import (
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
 	"github.com/open-component-model/ocm-controller/pkg/event"
 	"github.com/open-component-model/ocm-controller/pkg/ocm"
+	"github.com/open-component-model/ocm-controller/pkg/snapshot"
 )
 
 // FluxDeployerReconciler reconciles a FluxDeployer object.
@@ -52,12 +54,15 @@ type FluxDeployerReconciler struct {
 
 	CertSecretName string
 	Cache          cache.Cache
+	// Signer is optional. If set, the created OCIRepository verifies the snapshot signature.
+	Signer snapshot.Signer
 }
 
 // +kubebuilder:rbac:groups=delivery.ocm.software,resources=fluxdeployers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=delivery.ocm.software,resources=fluxdeployers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=delivery.ocm.software,resources=fluxdeployers/finalizers,verbs=update
 // +kubebuilder:rbac:groups=delivery.ocm.software,resources=snapshots,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories;helmrepositories,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kustomize.toolkit.fluxcd.io,resources=kustomizations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleases,verbs=get;list;watch;create;update;patch;delete
@@ -167,10 +172,33 @@ func (r *FluxDeployerReconciler) reconcile(
 		)
 	}
 
+	// a pinned revision overrides the tags of the latest snapshot for both kustomizations and helm releases.
+	revisionTag := ""
+	if obj.Spec.Revision != "" {
+		revision, ok := snapshot.GetRevision(obj.Spec.Revision)
+		if !ok {
+			msg := fmt.Sprintf("revision %s is not retained by snapshot %s", obj.Spec.Revision, snapshot.Name)
+			logger.Info(msg)
+			status.MarkNotReady(r.EventRecorder, obj, v1alpha1.SnapshotRevisionNotFoundReason, msg)
+
+			return ctrl.Result{RequeueAfter: r.RetryInterval}, nil
+		}
+
+		revisionTag = revision.Tag
+
+		// the revision might have been rendered from other inputs and is then retained in another repository.
+		snapshotURL = fmt.Sprintf("oci://%s/%s", r.RegistryServiceName, revision.GetRepository(snapshotRepo))
+	}
+
 	// create kustomization
 	if obj.Spec.KustomizationTemplate != nil {
 		// can't check for helm content as we don't know where things are or what content to check for
-		if err := r.createKustomizationSources(ctx, obj, snapshotURL, snapshot.Spec.Tag); err != nil {
+		tag := snapshot.Spec.Tag
+		if revisionTag != "" {
+			tag = revisionTag
+		}
+
+		if err := r.createKustomizationSources(ctx, obj, snapshotURL, tag); err != nil {
 			msg := "failed to create kustomization sources"
 			logger.Error(err, msg)
 			conditions.MarkFalse(
@@ -197,6 +225,9 @@ func (r *FluxDeployerReconciler) reconcile(
 		if v, ok := snapshot.Spec.Identity[v1alpha1.ResourceHelmChartVersion]; ok {
 			tag = v
 		}
+		if revisionTag != "" {
+			tag = revisionTag
+		}
 
 		if err := r.createHelmSources(ctx, obj, snapshotURL, tag); err != nil {
 			msg := "failed to create helm sources"
@@ -295,6 +326,21 @@ func (r *FluxDeployerReconciler) reconcileOCIRepo(
 	obj *v1alpha1.FluxDeployer,
 	url, tag string,
 ) error {
+	var verify *sourcev1.OCIRepositoryVerification
+	if r.Signer != nil {
+		secretName, err := r.reconcileVerificationSecret(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile verification secret: %w", err)
+		}
+
+		verify = &sourcev1.OCIRepositoryVerification{
+			Provider: snapshot.CosignProvider,
+			SecretRef: &meta.LocalObjectReference{
+				Name: secretName,
+			},
+		}
+	}
+
 	ociRepoCR := &sourcev1.OCIRepository{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: obj.GetNamespace(),
@@ -317,6 +363,7 @@ func (r *FluxDeployerReconciler) reconcileOCIRepo(
 			Reference: &sourcev1.OCIRepositoryRef{
 				Tag: tag,
 			},
+			Verify: verify,
 		}
 
 		return nil
@@ -330,6 +377,44 @@ func (r *FluxDeployerReconciler) reconcileOCIRepo(
 	return nil
 }
 
+// reconcileVerificationSecret copies the public key of the snapshot signer into a Secret next to
+// the OCIRepository, so Flux can verify the snapshot without access to the private key.
+func (r *FluxDeployerReconciler) reconcileVerificationSecret(
+	ctx context.Context,
+	obj *v1alpha1.FluxDeployer,
+) (string, error) {
+	publicKey, err := r.Signer.PublicKey(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshot signing public key: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName() + "-verification",
+		},
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.ObjectMeta.CreationTimestamp.IsZero() {
+			if err := controllerutil.SetOwnerReference(obj, secret, r.Scheme); err != nil {
+				return fmt.Errorf("failed to set owner reference on verification secret: %w", err)
+			}
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			snapshot.CosignPublicKey: publicKey,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create or update verification secret: %w", err)
+	}
+
+	return secret.GetName(), nil
+}
+
 func (r *FluxDeployerReconciler) reconcileKustomization(
 	ctx context.Context,
 	obj *v1alpha1.FluxDeployer,
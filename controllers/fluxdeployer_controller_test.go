@@ -2,6 +2,11 @@ package controllers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,6 +18,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -20,6 +26,8 @@ import (
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
 	"github.com/open-component-model/ocm-controller/pkg/cache/fakes"
+	"github.com/open-component-model/ocm-controller/pkg/ocm"
+	ocmsnapshot "github.com/open-component-model/ocm-controller/pkg/snapshot"
 	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
 )
 
@@ -157,3 +165,272 @@ func TestFluxDeployerReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestFluxDeployerReconcileWithSnapshotSigning(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	signingSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "snapshot-signing",
+			Namespace: "ocm-system",
+		},
+		Data: map[string][]byte{
+			ocmsnapshot.CosignPrivateKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}),
+		},
+	}
+
+	deployer := &v1alpha1.FluxDeployer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployer",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.FluxDeployerSpec{
+			SourceRef: v1alpha1.ObjectReference{
+				NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+					Name:      "test-resource",
+					Namespace: "default",
+					Kind:      "Resource",
+				},
+			},
+			KustomizationTemplate: &kustomizev1.KustomizationSpec{
+				Path: "./",
+			},
+		},
+	}
+	resourceV1 := &v1alpha1.Resource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-resource",
+			Namespace: "default",
+		},
+		Status: v1alpha1.ResourceStatus{
+			SnapshotName: "test-snapshot",
+		},
+	}
+	snapshot := &v1alpha1.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.SnapshotSpec{
+			Identity: ocmmetav1.Identity{
+				v1alpha1.ComponentNameKey:    "component-name",
+				v1alpha1.ComponentVersionKey: "v0.0.1",
+				v1alpha1.ResourceNameKey:     "resource-name",
+				v1alpha1.ResourceVersionKey:  "v0.0.5",
+			},
+			Digest: "digest-1",
+			Tag:    "1234",
+		},
+	}
+	conditions.MarkTrue(snapshot, meta.ReadyCondition, meta.SucceededReason, "Snapshot with name '%s' is ready", snapshot.Name)
+
+	client := env.FakeKubeClient(
+		WithAddToScheme(helmv2.AddToScheme),
+		WithAddToScheme(sourcev1.AddToScheme),
+		WithAddToScheme(kustomizev1.AddToScheme),
+		WithObjects(snapshot, deployer, resourceV1, signingSecret),
+	)
+	recorder := record.NewFakeRecorder(32)
+	dc := env.FakeDynamicKubeClient(WithObjects(snapshot, deployer, resourceV1))
+
+	sr := FluxDeployerReconciler{
+		Client:              client,
+		Scheme:              env.scheme,
+		EventRecorder:       recorder,
+		RegistryServiceName: "127.0.0.1:5000",
+		DynamicClient:       dc,
+		Cache:               &fakes.FakeCache{},
+		Signer:              ocmsnapshot.NewCosignSigner(client, nil, "127.0.0.1:5000", signingSecret.Name, signingSecret.Namespace),
+	}
+
+	_, err = sr.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      deployer.Name,
+			Namespace: deployer.Namespace,
+		},
+	})
+	require.NoError(t, err)
+
+	ociRepo := &sourcev1.OCIRepository{}
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: deployer.Name}, ociRepo))
+	require.NotNil(t, ociRepo.Spec.Verify)
+	assert.Equal(t, ocmsnapshot.CosignProvider, ociRepo.Spec.Verify.Provider)
+	require.NotNil(t, ociRepo.Spec.Verify.SecretRef)
+
+	verificationSecret := &corev1.Secret{}
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{
+		Namespace: "default",
+		Name:      ociRepo.Spec.Verify.SecretRef.Name,
+	}, verificationSecret))
+	assert.NotContains(t, verificationSecret.Data, ocmsnapshot.CosignPrivateKey)
+
+	block, _ := pem.Decode(verificationSecret.Data[ocmsnapshot.CosignPublicKey])
+	require.NotNil(t, block)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(pub))
+
+	close(recorder.Events)
+}
+
+func TestFluxDeployerReconcileWithRevision(t *testing.T) {
+	testcases := []struct {
+		name        string
+		revision    string
+		ready       bool
+		expectedTag string
+		// previous is set if the revision has been rendered from the previous component version.
+		previous bool
+	}{
+		{
+			name:        "deploys the retained revision referenced by digest",
+			revision:    "digest-1",
+			ready:       true,
+			expectedTag: "v0.0.5-digest-1",
+		},
+		{
+			name:        "deploys the retained revision referenced by tag",
+			revision:    "v0.0.5-digest-2",
+			ready:       true,
+			expectedTag: "v0.0.5-digest-2",
+		},
+		{
+			name:        "deploys the revision retained in the repository of a previous component version",
+			revision:    "digest-0",
+			ready:       true,
+			expectedTag: "v0.0.5-digest-0",
+			previous:    true,
+		},
+		{
+			name:        "deploys a revision retained without repository from the repository of the snapshot",
+			revision:    "digest-legacy",
+			ready:       true,
+			expectedTag: "v0.0.5-digest-legacy",
+		},
+		{
+			name:     "is not ready if the revision is not retained",
+			revision: "digest-unknown",
+			ready:    false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployer := &v1alpha1.FluxDeployer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployer",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.FluxDeployerSpec{
+					SourceRef: v1alpha1.ObjectReference{
+						NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+							Name:      "test-resource",
+							Namespace: "default",
+							Kind:      "Resource",
+						},
+					},
+					KustomizationTemplate: &kustomizev1.KustomizationSpec{
+						Path: "./",
+					},
+					Revision: tc.revision,
+				},
+			}
+			resourceV1 := &v1alpha1.Resource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-resource",
+					Namespace: "default",
+				},
+				Status: v1alpha1.ResourceStatus{
+					SnapshotName: "test-snapshot",
+				},
+			}
+			identity := ocmmetav1.Identity{
+				v1alpha1.ComponentNameKey:    "component-name",
+				v1alpha1.ComponentVersionKey: "v0.0.1",
+				v1alpha1.ResourceNameKey:     "resource-name",
+				v1alpha1.ResourceVersionKey:  "v0.0.5",
+			}
+			repository, err := ocm.ConstructRepositoryName(identity)
+			require.NoError(t, err)
+
+			// the component version has been bumped since digest-0 was rendered, which moved the snapshot to another repository.
+			previousIdentity := ocmmetav1.Identity{}
+			for k, v := range identity {
+				previousIdentity[k] = v
+			}
+			previousIdentity[v1alpha1.ComponentVersionKey] = "v0.0.0"
+			previousRepository, err := ocm.ConstructRepositoryName(previousIdentity)
+			require.NoError(t, err)
+			require.NotEqual(t, repository, previousRepository)
+
+			snapshot := &v1alpha1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-snapshot",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.SnapshotSpec{
+					Identity: identity,
+					Digest:   "digest-2",
+					Tag:      "v0.0.5",
+				},
+				Status: v1alpha1.SnapshotStatus{
+					Revisions: []v1alpha1.SnapshotRevision{
+						{Digest: "digest-2", Tag: "v0.0.5-digest-2", Repository: repository},
+						{Digest: "digest-1", Tag: "v0.0.5-digest-1", Repository: repository},
+						{Digest: "digest-0", Tag: "v0.0.5-digest-0", Repository: previousRepository},
+						{Digest: "digest-legacy", Tag: "v0.0.5-digest-legacy"},
+					},
+				},
+			}
+			conditions.MarkTrue(snapshot, meta.ReadyCondition, meta.SucceededReason, "Snapshot with name '%s' is ready", snapshot.Name)
+
+			client := env.FakeKubeClient(
+				WithAddToScheme(helmv2.AddToScheme),
+				WithAddToScheme(sourcev1.AddToScheme),
+				WithAddToScheme(kustomizev1.AddToScheme),
+				WithObjects(snapshot, deployer, resourceV1),
+			)
+			recorder := record.NewFakeRecorder(32)
+			dc := env.FakeDynamicKubeClient(WithObjects(snapshot, deployer, resourceV1))
+
+			sr := FluxDeployerReconciler{
+				Client:              client,
+				Scheme:              env.scheme,
+				EventRecorder:       recorder,
+				RegistryServiceName: "127.0.0.1:5000",
+				DynamicClient:       dc,
+				Cache:               &fakes.FakeCache{},
+			}
+
+			_, err = sr.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      deployer.Name,
+					Namespace: deployer.Namespace,
+				},
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: deployer.Name}, deployer))
+			assert.Equal(t, tc.ready, conditions.IsReady(deployer))
+
+			if !tc.ready {
+				assert.Equal(t, v1alpha1.SnapshotRevisionNotFoundReason, conditions.GetReason(deployer, meta.ReadyCondition))
+
+				return
+			}
+
+			ociRepo := &sourcev1.OCIRepository{}
+			require.NoError(t, client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: deployer.Name}, ociRepo))
+			assert.Equal(t, tc.expectedTag, ociRepo.Spec.Reference.Tag)
+
+			expectedRepository := repository
+			if tc.previous {
+				expectedRepository = previousRepository
+			}
+			assert.Equal(t, "oci://127.0.0.1:5000/"+expectedRepository, ociRepo.Spec.URL)
+		})
+	}
+}
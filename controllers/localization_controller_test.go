@@ -721,6 +721,7 @@ localization:
 				Cache:         cache,
 				MutationReconciler: MutationReconcileLooper{
 					Client:         client,
+					APIReader:      client,
 					DynamicClient:  dynClient,
 					Scheme:         env.scheme,
 					OCMClient:      fakeOcm,
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ocmcore "ocm.software/ocm/api/ocm"
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+	"github.com/open-component-model/ocm-controller/pkg/ocm"
+)
+
+// ociArtifactDigestNormalisation is the normalisation of resource digests which are the digest of the
+// artifact manifest.
+const ociArtifactDigestNormalisation = "ociArtifactDigest/v1"
+
+// digestResolver resolves the digests pinned by localization rules. Digests resolved from the
+// registry are recorded, so each tag is only resolved once.
+type digestResolver struct {
+	ctx      context.Context
+	octx     ocmcore.Context
+	client   ocm.Contract
+	recorded map[string]string
+	resolved []v1alpha1.ResolvedDigest
+	// platforms caches the manifest digests resolved for platforms during a reconciliation.
+	platforms map[string]string
+}
+
+// newDigestResolver returns a resolver which uses the digests recorded in the status before
+// resolving tags against the registry.
+func newDigestResolver(ctx context.Context, octx ocmcore.Context, client ocm.Contract, recorded []v1alpha1.ResolvedDigest) *digestResolver {
+	r := &digestResolver{
+		ctx:       ctx,
+		octx:      octx,
+		client:    client,
+		recorded:  make(map[string]string, len(recorded)),
+		platforms: make(map[string]string),
+	}
+
+	for _, d := range recorded {
+		r.recorded[d.Reference] = d.Digest
+	}
+
+	return r
+}
+
+// resolve returns the digest of the image. Digest references and manifest digests recorded in the
+// component descriptor are used as they are, tags are resolved against the registry.
+func (r *digestResolver) resolve(ref name.Reference, digestSpec *ocmmetav1.DigestSpec) (string, error) {
+	if d, ok := ref.(name.Digest); ok {
+		return d.DigestStr(), nil
+	}
+
+	if digestSpec != nil && digestSpec.NormalisationAlgorithm == ociArtifactDigestNormalisation && digestSpec.Value != "" {
+		algorithm := strings.ToLower(strings.ReplaceAll(digestSpec.HashAlgorithm, "-", ""))
+
+		return algorithm + ":" + digestSpec.Value, nil
+	}
+
+	key := ref.Name()
+	for _, d := range r.resolved {
+		if d.Reference == key {
+			return d.Digest, nil
+		}
+	}
+
+	dgst, ok := r.recorded[key]
+	if !ok {
+		var err error
+		if dgst, err = r.client.ResolveImageDigest(r.ctx, r.octx, ref); err != nil {
+			return "", fmt.Errorf("failed to resolve digest: %w", err)
+		}
+	}
+
+	r.resolved = append(r.resolved, v1alpha1.ResolvedDigest{
+		Reference: key,
+		Digest:    dgst,
+	})
+
+	return dgst, nil
+}
+
+// resolvePlatform returns the digest of the manifest for the platform if the image points to an
+// image index. Platform manifests are resolved on every reconciliation and aren't recorded.
+func (r *digestResolver) resolvePlatform(ref name.Reference, platform v1.Platform) (string, error) {
+	key := ref.Name() + "@" + platform.String()
+	if dgst, ok := r.platforms[key]; ok {
+		return dgst, nil
+	}
+
+	dgst, err := r.client.ResolvePlatformDigest(r.ctx, r.octx, ref, platform)
+	if err != nil {
+		return "", err
+	}
+
+	r.platforms[key] = dgst
+
+	return dgst, nil
+}
+
+// localizationPlatform returns the platform selected by the rule. The default platform of the
+// mutation only applies to ociImage resources.
+func localizationPlatform(l configdata.LocalizationRule, spec *v1alpha1.MutationSpec, resourceType string) *v1.Platform {
+	switch {
+	case l.Platform != nil:
+		return &v1.Platform{
+			OS:           l.Platform.OS,
+			Architecture: l.Platform.Architecture,
+			Variant:      l.Platform.Variant,
+		}
+	case spec.Platform != nil && resourceType == ociImageResourceType:
+		return &v1.Platform{
+			OS:           spec.Platform.OS,
+			Architecture: spec.Platform.Architecture,
+			Variant:      spec.Platform.Variant,
+		}
+	default:
+		return nil
+	}
+}
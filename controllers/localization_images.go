@@ -0,0 +1,502 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+	"ocm.software/ocm/api/datacontext"
+	ocmcore "ocm.software/ocm/api/ocm"
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// ociImageResourceType is the type of OCM resources holding container images.
+const ociImageResourceType = "ociImage"
+
+// podSpecPaths are the paths of the pod spec within the workloads scanned by automatic localization.
+var podSpecPaths = map[string]string{
+	"Pod":         "$.spec",
+	"Deployment":  "$.spec.template.spec",
+	"StatefulSet": "$.spec.template.spec",
+	"DaemonSet":   "$.spec.template.spec",
+	"Job":         "$.spec.template.spec",
+	"CronJob":     "$.spec.jobTemplate.spec.template.spec",
+}
+
+// containerImagePaths are the paths of the container images within a pod spec.
+var containerImagePaths = []string{
+	"containers[*].image",
+	"initContainers[*].image",
+	"ephemeralContainers[*].image",
+}
+
+// imageResource is an ociImage resource of the component graph.
+type imageResource struct {
+	component string
+	resource  string
+	reference name.Reference
+	label     string
+}
+
+// imageMatcher returns the resource matching image, if any.
+type imageMatcher func(image string) (imageResource, bool)
+
+// mutateAutoLocalize rewrites the images found in the source to the references of the matching ociImage
+// resources. If sourceDir is empty the source data is extracted first, otherwise the images in sourceDir
+// are rewritten in place and the returned identity is empty.
+func (m *MutationReconcileLooper) mutateAutoLocalize(
+	ctx context.Context,
+	obj v1alpha1.MutationObject,
+	mutationSpec *v1alpha1.MutationSpec,
+	sourceData []byte,
+	sourceDir string,
+) (string, ocmmetav1.Identity, error) {
+	ref := componentVersionReference(mutationSpec)
+	if ref == nil {
+		return "", nil, errors.New("automatic localization requires a ComponentVersion as source or config reference")
+	}
+
+	cv, err := m.getComponentVersion(ctx, ref)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get component version: %w", err)
+	}
+
+	if !conditions.IsReady(cv) || cv.GetRepositoryURL() == "" {
+		return "", nil, fmt.Errorf("component version is not ready yet")
+	}
+
+	octx, err := m.OCMClient.CreateAuthenticatedOCMContext(ctx, cv)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create authenticated client: %w", err)
+	}
+	defer func() {
+		_ = datacontext.Close(octx)
+	}()
+
+	compvers, err := m.OCMClient.GetComponentVersion(ctx, octx, cv.GetRepositoryURL(), cv.Spec.Component, cv.Status.ReconciledVersion)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get component version: %w", err)
+	}
+	defer compvers.Close()
+
+	resources, err := collectImageResources(octx, compvers, mutationSpec.AutoLocalize.MatchLabel, map[string]bool{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	rewriter, err := m.registryRewriter(ctx, obj)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for i := range resources {
+		if resources[i].reference, err = rewriter.rewrite(resources[i].reference); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var identity ocmmetav1.Identity
+	if sourceDir == "" {
+		if sourceDir, err = extractSourceData(sourceData, "auto-localize-"); err != nil {
+			return "", nil, err
+		}
+
+		identity, err = strategyIdentity(obj, struct {
+			AutoLocalize *v1alpha1.AutoLocalization  `json:"autoLocalize"`
+			Output       v1alpha1.LocalizationOutput `json:"output,omitempty"`
+			Version      string                      `json:"version"`
+		}{
+			AutoLocalize: mutationSpec.AutoLocalize,
+			Output:       mutationSpec.LocalizationOutput,
+			Version:      cv.Status.ReconciledVersion,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+
+		obj.GetStatus().LatestConfigVersion = cv.Status.ReconciledVersion
+	}
+
+	kustomize := mutationSpec.LocalizationOutput == v1alpha1.KustomizeImagesOutput
+
+	localized, unmatched, err := localizeImages(sourceDir, mutationSpec.AutoLocalize.ExtraPaths, matchImage(resources), !kustomize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if kustomize {
+		if err := kustomizeLocalizedImages(sourceDir, localized); err != nil {
+			return "", nil, fmt.Errorf("failed to generate kustomize images: %w", err)
+		}
+	}
+
+	log.FromContext(ctx).Info("localized images", "localized", len(localized), "unmatched", len(unmatched))
+
+	obj.GetStatus().LocalizedImages = localized
+	obj.GetStatus().UnmatchedImages = unmatched
+
+	return sourceDir, identity, nil
+}
+
+// componentVersionReference returns the first ComponentVersion referenced by the mutation spec.
+func componentVersionReference(spec *v1alpha1.MutationSpec) *v1alpha1.ObjectReference {
+	for _, ref := range []*v1alpha1.ObjectReference{&spec.SourceRef, spec.ConfigRef} {
+		if ref != nil && ref.Kind == v1alpha1.ComponentVersionKind {
+			return ref
+		}
+	}
+
+	return nil
+}
+
+// collectImageResources returns the ociImage resources of the component version and its references.
+// The resources of the component version precede the ones of its references.
+func collectImageResources(
+	octx ocmcore.Context,
+	cva ocmcore.ComponentVersionAccess,
+	matchLabel string,
+	visited map[string]bool,
+) ([]imageResource, error) {
+	key := cva.GetName() + ":" + cva.GetVersion()
+	if visited[key] {
+		return nil, nil
+	}
+	visited[key] = true
+
+	var result []imageResource
+	for _, res := range cva.GetResources() {
+		meta := res.Meta()
+		if meta.GetType() != ociImageResourceType {
+			continue
+		}
+
+		reference, err := accessReference(octx, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve image of resource %s in %s: %w", meta.GetName(), key, err)
+		}
+
+		image := imageResource{
+			component: cva.GetName(),
+			resource:  meta.GetName(),
+			reference: reference,
+		}
+
+		if matchLabel != "" {
+			if _, err := meta.Labels.GetValue(matchLabel, &image.label); err != nil {
+				return nil, fmt.Errorf("failed to get label %s of resource %s in %s: %w", matchLabel, meta.GetName(), key, err)
+			}
+		}
+
+		result = append(result, image)
+	}
+
+	for _, ref := range cva.GetDescriptor().References {
+		nested, err := cva.Repository().LookupComponentVersion(ref.ComponentName, ref.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referenced component version %s:%s: %w", ref.ComponentName, ref.Version, err)
+		}
+
+		images, err := collectImageResources(octx, nested, matchLabel, visited)
+		_ = nested.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, images...)
+	}
+
+	return result, nil
+}
+
+// matchImage returns a matcher selecting the resource whose match label names the repository of
+// the image, or otherwise a resource with the same repository path. Both references are normalised,
+// so an image without a registry matches a resource on Docker Hub including the implicit library
+// namespace. The registry is ignored as components are usually transferred to another registry, but
+// a resource on the registry of the image is preferred when several resources share the path.
+func matchImage(resources []imageResource) imageMatcher {
+	return func(image string) (imageResource, bool) {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return imageResource{}, false
+		}
+
+		repository := ref.Context().Name()
+
+		for _, res := range resources {
+			if res.label == "" {
+				continue
+			}
+
+			if repo, err := name.NewRepository(res.label); err == nil && repo.Name() == repository {
+				return res, true
+			}
+		}
+
+		var (
+			match imageResource
+			found bool
+		)
+
+		for _, res := range resources {
+			if res.reference.Context().Name() == repository {
+				return res, true
+			}
+
+			if !found && res.reference.Context().RepositoryStr() == ref.Context().RepositoryStr() {
+				match, found = res, true
+			}
+		}
+
+		return match, found
+	}
+}
+
+// localizeImages rewrites the images found in the YAML files of dir which are matched by match and
+// returns the localized and the unmatched images. The container images of workloads are left untouched
+// unless rewriteContainers is set, as they can be localized by a kustomize images transformer instead.
+// Files which can't be parsed as YAML are skipped.
+func localizeImages(dir string, extraPaths []string, match imageMatcher, rewriteContainers bool) ([]v1alpha1.LocalizedImage, []v1alpha1.LocalizedImage, error) {
+	extra := make([]imagePath, 0, len(extraPaths))
+	for _, expression := range extraPaths {
+		path, err := yamlpath.NewPath(expression)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid image path %q: %w", expression, err)
+		}
+
+		extra = append(extra, imagePath{expression: expression, path: path})
+	}
+
+	var localized, unmatched []v1alpha1.LocalizedImage
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if ext := filepath.Ext(file); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		docs, err := decodeDocuments(content)
+		if err != nil {
+			//nolint:nilerr // not every YAML file is a manifest, e.g. Helm templates
+			return nil
+		}
+
+		changed := false
+		for _, doc := range docs {
+			for _, found := range findImages(doc, extra) {
+				image := v1alpha1.LocalizedImage{
+					File:   filepath.ToSlash(rel),
+					Object: found.object,
+					Path:   found.path,
+					Image:  found.node.Value,
+				}
+
+				res, ok := match(found.node.Value)
+				if !ok {
+					unmatched = append(unmatched, image)
+
+					continue
+				}
+
+				image.Localized = res.reference.String()
+				image.Component = res.component
+				image.Resource = res.resource
+				localized = append(localized, image)
+
+				if found.node.Value != image.Localized && (rewriteContainers || !found.container) {
+					found.node.Value = image.Localized
+					changed = true
+				}
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+
+		out, err := encodeDocuments(docs)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", rel, err)
+		}
+
+		return os.WriteFile(file, out, FSOwnerReadWrite)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to localize images: %w", err)
+	}
+
+	return localized, unmatched, nil
+}
+
+// imagePath is a compiled path expression selecting image references.
+type imagePath struct {
+	expression string
+	path       *yamlpath.Path
+}
+
+// foundImage is an image reference within a document.
+type foundImage struct {
+	object    string
+	path      string
+	node      *yaml.Node
+	container bool
+}
+
+// findImages returns the container images of the workload held by doc and the images selected
+// by the extra paths.
+func findImages(doc *yaml.Node, extra []imagePath) []foundImage {
+	kind := lookupScalar(doc, "kind")
+	object := kind
+	if objName := lookupScalar(doc, "metadata", "name"); objName != "" {
+		object = kind + "/" + objName
+	}
+
+	var (
+		result []foundImage
+		seen   = map[*yaml.Node]bool{}
+	)
+
+	add := func(p imagePath, container bool) {
+		nodes, err := p.path.Find(doc)
+		if err != nil {
+			return
+		}
+
+		for _, node := range nodes {
+			if node.Kind != yaml.ScalarNode || node.Value == "" || seen[node] {
+				continue
+			}
+
+			seen[node] = true
+			result = append(result, foundImage{object: object, path: p.expression, node: node, container: container})
+		}
+	}
+
+	if podSpec, ok := podSpecPaths[kind]; ok {
+		for _, p := range containerImagePaths {
+			expression := podSpec + "." + p
+			// the expressions are constant and known to be valid
+			path, _ := yamlpath.NewPath(expression)
+			add(imagePath{expression: expression, path: path}, true)
+		}
+	}
+
+	for _, p := range extra {
+		add(p, false)
+	}
+
+	return result
+}
+
+// isContainerImagePath reports whether the path expression selects the container images of a workload.
+func isContainerImagePath(expression string) bool {
+	for _, podSpec := range podSpecPaths {
+		for _, p := range containerImagePaths {
+			if expression == podSpec+"."+p {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// lookupScalar returns the value of the scalar at the given keys of the mapping held by doc.
+func lookupScalar(doc *yaml.Node, keys ...string) string {
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return ""
+		}
+
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+
+				break
+			}
+		}
+
+		if next == nil {
+			return ""
+		}
+
+		node = next
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return ""
+	}
+
+	return node.Value
+}
+
+// decodeDocuments decodes all documents of a multi document YAML file.
+func decodeDocuments(content []byte) ([]*yaml.Node, error) {
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+
+			return nil, err
+		}
+
+		docs = append(docs, doc)
+	}
+}
+
+// encodeDocuments encodes the documents into a multi document YAML file.
+func encodeDocuments(docs []*yaml.Node) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/konfig"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// imageRuleNames are the substitutions of a localization rule which can be expressed as a kustomize image.
+var imageRuleNames = map[string]bool{
+	"image":                    true,
+	"registry":                 true,
+	"repository":               true,
+	"fullyQualifiedRepository": true,
+	"tag":                      true,
+	"digest":                   true,
+}
+
+// imageRule is a localization rule applied to a single file.
+type imageRule struct {
+	rule  string
+	file  string
+	image string
+	// paths are the paths of the substitutions of the rule by their name.
+	paths map[string]string
+	// values are the localized values of the substitutions of the rule by their name.
+	values map[string][]byte
+}
+
+// kustomizeImages merges the localized images into the images transformer of the kustomization in
+// the root of dir instead of substituting them in the files. Every localization rule is applied to a
+// file as a single image, which replaces the image found in the file with the image its resource
+// resolves to. The image in the file is read from the path of the image or fullyQualifiedRepository
+// substitution, or composed of the values at the paths of the registry and repository substitutions.
+func kustomizeImages(dir string, rules substitutions) error {
+	var (
+		order  []string
+		groups = map[string]*imageRule{}
+	)
+
+	for _, rule := range rules {
+		if rule.selector != nil {
+			return fmt.Errorf("%s selects documents, which can't be expressed as a kustomize image", rule.rule)
+		}
+
+		if !imageRuleNames[rule.Name] || rule.image == "" {
+			return fmt.Errorf("the %s substitution of %s can't be expressed as a kustomize image", rule.Name, rule.FilePath)
+		}
+
+		expanded, _, err := substitutions{rule}.expand(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range expanded {
+			key := rule.rule + "\x00" + sub.FilePath
+			group, ok := groups[key]
+			if !ok {
+				group = &imageRule{
+					rule:   rule.rule,
+					file:   sub.FilePath,
+					image:  rule.image,
+					paths:  map[string]string{},
+					values: map[string][]byte{},
+				}
+				groups[key] = group
+				order = append(order, key)
+			}
+
+			group.paths[sub.Name] = sub.ValuePath
+			group.values[sub.Name] = sub.Value
+		}
+	}
+
+	var images []kustypes.Image
+	for _, key := range order {
+		image, err := groups[key].kustomizeImage(dir)
+		if err != nil {
+			return err
+		}
+
+		images = mergeImages(images, image)
+	}
+
+	return writeKustomizeImages(dir, images)
+}
+
+// kustomizeImage returns the image transformer entry of the rule.
+func (r *imageRule) kustomizeImage(dir string) (kustypes.Image, error) {
+	var (
+		original string
+		err      error
+	)
+
+	switch {
+	case r.paths["image"] != "":
+		original, err = lookupValue(dir, r.file, r.paths["image"])
+	case r.paths["fullyQualifiedRepository"] != "":
+		original, err = lookupValue(dir, r.file, r.paths["fullyQualifiedRepository"])
+	case r.paths["repository"] != "":
+		if original, err = lookupValue(dir, r.file, r.paths["repository"]); err == nil && r.paths["registry"] != "" {
+			var registry string
+			if registry, err = lookupValue(dir, r.file, r.paths["registry"]); err == nil {
+				original = registry + "/" + original
+			}
+		}
+	default:
+		return kustypes.Image{}, fmt.Errorf("%s doesn't localize the image name in %s, which is required by the %s output",
+			r.rule, r.file, v1alpha1.KustomizeImagesOutput)
+	}
+
+	if err != nil {
+		return kustypes.Image{}, err
+	}
+
+	image := kustomizeImage(original, r.image)
+	if value, ok := r.values["digest"]; ok && image.Digest == "" {
+		if err := json.Unmarshal(value, &image.Digest); err != nil {
+			return kustypes.Image{}, fmt.Errorf("failed to decode localized digest: %w", err)
+		}
+	}
+
+	return image, nil
+}
+
+// kustomizeLocalizedImages merges the automatically localized container images into the images
+// transformer of the kustomization in the root of dir.
+func kustomizeLocalizedImages(dir string, localized []v1alpha1.LocalizedImage) error {
+	var images []kustypes.Image
+	for _, l := range localized {
+		if !isContainerImagePath(l.Path) || l.Image == l.Localized {
+			continue
+		}
+
+		images = mergeImages(images, kustomizeImage(l.Image, l.Localized))
+	}
+
+	return writeKustomizeImages(dir, images)
+}
+
+// writeKustomizeImages merges the images into the images transformer of the kustomization in the root
+// of dir. A kustomization listing all YAML files is generated if dir doesn't have one.
+func writeKustomizeImages(dir string, images []kustypes.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	if err := ensureKustomization(dir); err != nil {
+		return err
+	}
+
+	path, err := kustomizationFile(dir)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read kustomization: %w", err)
+	}
+
+	kus := kustypes.Kustomization{}
+	if err := yaml.Unmarshal(content, &kus); err != nil {
+		return fmt.Errorf("failed to decode kustomization: %w", err)
+	}
+
+	kus.Images = mergeImages(kus.Images, images...)
+
+	manifest, err := yaml.Marshal(kus)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, manifest, FSOwnerReadWrite)
+}
+
+// kustomizationFile returns the path of the kustomization file in dir.
+func kustomizationFile(dir string) (string, error) {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no kustomization found in %s", dir)
+}
+
+// lookupValue returns the value at the substitution path in the first document of the file holding it.
+func lookupValue(dir, file, path string) (string, error) {
+	filePath, err := securejoin.SecureJoin(dir, file)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	docs, err := decodeDocuments(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+
+	expression := path
+	if !strings.HasPrefix(expression, "$") {
+		expression = "$." + expression
+	}
+
+	p, err := yamlpath.NewPath(expression)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %w", path, err)
+	}
+
+	for _, doc := range docs {
+		nodes, err := p.Find(doc)
+		if err != nil {
+			continue
+		}
+
+		for _, node := range nodes {
+			if node.Kind == yamlv3.ScalarNode && node.Value != "" {
+				return node.Value, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no value found at %s in %s", path, file)
+}
+
+// kustomizeImage returns the image transformer entry replacing the original image with the localized one.
+func kustomizeImage(original, localized string) kustypes.Image {
+	originalName, _, _ := splitImage(original)
+	localizedName, tag, digest := splitImage(localized)
+
+	image := kustypes.Image{
+		Name:   originalName,
+		NewTag: tag,
+		Digest: digest,
+	}
+
+	if localizedName != originalName {
+		image.NewName = localizedName
+	}
+
+	return image
+}
+
+// splitImage splits an image reference into its name, tag and digest.
+func splitImage(image string) (string, string, string) {
+	var digest string
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, digest = image[:i], image[i+1:]
+	}
+
+	var tag string
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, tag = image[:i], image[i+1:]
+	}
+
+	return image, tag, digest
+}
+
+// mergeImages adds the images to the list, replacing the entries with the same name.
+func mergeImages(images []kustypes.Image, add ...kustypes.Image) []kustypes.Image {
+	for _, image := range add {
+		replaced := false
+		for i := range images {
+			if images[i].Name == image.Name {
+				images[i] = image
+				replaced = true
+
+				break
+			}
+		}
+
+		if !replaced {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"k8s.io/apimachinery/pkg/types"
+	ocmcore "ocm.software/ocm/api/ocm"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/git"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/github"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/helm"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/localblob"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/ociartifact"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/ociblob"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/s3"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/wget"
+	"ocm.software/ocm/api/ocm/ocmutils/localize"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+)
+
+// resourceLocation describes where the content of a resource is located.
+type resourceLocation struct {
+	// URL is the full location of the resource.
+	URL string
+	// Host is the host part of the URL, the bucket for S3 blobs.
+	Host string
+	// Path is the path part of the URL without the leading slash, the key for S3 blobs.
+	Path string
+	// Ref is the version of the resource at the location: the tag or digest of OCI artifacts,
+	// the chart version, the commit or ref of git repositories and the version of S3 blobs.
+	Ref string
+	// Reference is set for OCI artifacts only.
+	Reference name.Reference
+}
+
+// accessLocation returns the location the access of the resource points to.
+func accessLocation(octx ocmcore.Context, accSpec ocmcore.AccessSpec) (*resourceLocation, error) {
+	for {
+		switch x := accSpec.(type) {
+		case *ociartifact.AccessSpec:
+			return ociLocation(x.ImageReference)
+		case *ociblob.AccessSpec:
+			return ociLocation(fmt.Sprintf("%s@%s", x.Reference, x.Digest))
+		case *localblob.AccessSpec:
+			if x.GlobalAccess == nil {
+				return nil, errors.New("cannot determine location of local blob without global access")
+			}
+
+			var err error
+			if accSpec, err = octx.AccessSpecForSpec(x.GlobalAccess); err != nil {
+				return nil, err
+			}
+		case *helm.AccessSpec:
+			ref := x.Version
+			if _, version, ok := strings.Cut(x.HelmChart, ":"); ok && ref == "" {
+				ref = version
+			}
+
+			return urlLocation(x.HelmRepository, ref)
+		case *wget.AccessSpec:
+			return urlLocation(x.URL, "")
+		case *git.AccessSpec:
+			ref := x.Commit
+			if ref == "" {
+				ref = x.Ref
+			}
+
+			return urlLocation(x.RepoURL, ref)
+		case *github.AccessSpec:
+			return urlLocation(x.RepoURL, x.Commit)
+		case *s3.AccessSpec:
+			return &resourceLocation{
+				URL:  fmt.Sprintf("s3://%s/%s", x.Bucket, x.Key),
+				Host: x.Bucket,
+				Path: x.Key,
+				Ref:  x.Version,
+			}, nil
+		default:
+			return nil, fmt.Errorf("cannot determine location of access spec type %s", accSpec.GetType())
+		}
+	}
+}
+
+// ociLocation returns the location of an OCI artifact.
+func ociLocation(ref string) (*resourceLocation, error) {
+	pRef, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse access reference: %w", err)
+	}
+
+	return &resourceLocation{
+		URL:       "oci://" + pRef.Context().Name(),
+		Host:      pRef.Context().RegistryStr(),
+		Path:      pRef.Context().RepositoryStr(),
+		Ref:       pRef.Identifier(),
+		Reference: pRef,
+	}, nil
+}
+
+// urlLocation splits the URL into the parts of the location.
+func urlLocation(rawURL, ref string) (*resourceLocation, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url %s: %w", rawURL, err)
+	}
+
+	return &resourceLocation{
+		URL:  rawURL,
+		Host: u.Host,
+		Path: strings.TrimPrefix(u.Path, "/"),
+		Ref:  ref,
+	}, nil
+}
+
+// snapshotLocation returns the location of the snapshot of the Resource object in the in-cluster registry.
+// The Resource has to be in the namespace of the localized object, the config data must not reveal the
+// snapshots of other namespaces.
+func (m *MutationReconcileLooper) snapshotLocation(ctx context.Context, item *configdata.SnapshotItem, namespace string) (*resourceLocation, error) {
+	if item.Namespace != "" && item.Namespace != namespace {
+		return nil, fmt.Errorf("resource %s/%s is not in namespace %s of the localized object", item.Namespace, item.Name, namespace)
+	}
+
+	resource := &v1alpha1.Resource{}
+	if err := m.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: item.Name}, resource); err != nil {
+		return nil, fmt.Errorf("failed to get resource %s/%s: %w", namespace, item.Name, err)
+	}
+
+	if resource.Status.SnapshotName == "" {
+		return nil, fmt.Errorf("resource %s/%s has no snapshot yet", namespace, item.Name)
+	}
+
+	snapshot := &v1alpha1.Snapshot{}
+	if err := m.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: resource.Status.SnapshotName}, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to get snapshot %s/%s: %w", namespace, resource.Status.SnapshotName, err)
+	}
+
+	if snapshot.Status.RepositoryURL == "" {
+		return nil, fmt.Errorf("snapshot %s/%s has not been pushed yet", namespace, snapshot.Name)
+	}
+
+	return urlLocation(snapshot.Status.RepositoryURL, snapshot.Status.LastReconciledTag)
+}
+
+// addLocation adds the substitutions of the location parts requested by the rule.
+func addLocation(l configdata.LocalizationRule, location *resourceLocation, localizations *localize.Substitutions) error {
+	for _, part := range []struct {
+		name, path, value string
+	}{
+		{"url", l.URL, location.URL},
+		{"host", l.Host, location.Host},
+		{"path", l.Path, location.Path},
+		{"ref", l.Ref, location.Ref},
+	} {
+		if part.path == "" {
+			continue
+		}
+
+		if err := localizations.Add(part.name, l.File, part.path, part.value); err != nil {
+			return fmt.Errorf("failed to add %s: %w", part.name, err)
+		}
+	}
+
+	return nil
+}
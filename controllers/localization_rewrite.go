@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// rewriteRule is a compiled rule of a registry rewrite policy.
+type rewriteRule struct {
+	policy  string
+	prefix  string
+	regex   *regexp.Regexp
+	replace string
+}
+
+// registryRewriter rewrites image references with the registry rewrite policies that apply to a
+// mutation object and records the rewritten references in its status.
+type registryRewriter struct {
+	rules  []rewriteRule
+	status *v1alpha1.MutationStatus
+}
+
+// registryRewriter returns the rewriter for the cluster policies followed by the policies of the object's
+// namespace, so the mirrors enforced for the cluster can't be bypassed by the policies of a namespace.
+// Policies of the same scope are applied ordered by name.
+func (m *MutationReconcileLooper) registryRewriter(ctx context.Context, obj v1alpha1.MutationObject) (*registryRewriter, error) {
+	policies := &v1alpha1.RegistryRewritePolicyList{}
+	if err := m.Client.List(ctx, policies, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list registry rewrite policies: %w", err)
+	}
+
+	clusterPolicies := &v1alpha1.ClusterRegistryRewritePolicyList{}
+	if err := m.Client.List(ctx, clusterPolicies); err != nil {
+		return nil, fmt.Errorf("failed to list cluster registry rewrite policies: %w", err)
+	}
+
+	sort.Slice(policies.Items, func(i, j int) bool {
+		return policies.Items[i].Name < policies.Items[j].Name
+	})
+	sort.Slice(clusterPolicies.Items, func(i, j int) bool {
+		return clusterPolicies.Items[i].Name < clusterPolicies.Items[j].Name
+	})
+
+	rewriter := &registryRewriter{
+		status: obj.GetStatus(),
+	}
+
+	for _, p := range clusterPolicies.Items {
+		key := fmt.Sprintf("%s/%s", v1alpha1.ClusterRegistryRewritePolicyKind, p.Name)
+		if err := rewriter.addRules(key, p.Spec.Rules); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range policies.Items {
+		key := fmt.Sprintf("%s/%s/%s", v1alpha1.RegistryRewritePolicyKind, p.Namespace, p.Name)
+		if err := rewriter.addRules(key, p.Spec.Rules); err != nil {
+			return nil, err
+		}
+	}
+
+	return rewriter, nil
+}
+
+func (r *registryRewriter) addRules(policy string, rules []v1alpha1.RegistryRewriteRule) error {
+	for i, rule := range rules {
+		if (rule.Prefix == "") == (rule.Regex == "") {
+			return fmt.Errorf("rule %d of %s must set either prefix or regex", i, policy)
+		}
+
+		compiled := rewriteRule{
+			policy:  policy,
+			prefix:  rule.Prefix,
+			replace: rule.Replace,
+		}
+
+		if rule.Regex != "" {
+			regex, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("rule %d of %s has an invalid regex: %w", i, policy, err)
+			}
+
+			compiled.regex = regex
+		}
+
+		r.rules = append(r.rules, compiled)
+	}
+
+	return nil
+}
+
+// rewrite returns the reference rewritten by the first matching rule. References not matched by
+// any rule are returned as they are.
+func (r *registryRewriter) rewrite(ref name.Reference) (name.Reference, error) {
+	original := qualifiedReference(ref)
+
+	for _, rule := range r.rules {
+		var rewritten string
+		switch {
+		case rule.regex != nil:
+			if !rule.regex.MatchString(original) {
+				continue
+			}
+
+			rewritten = rule.regex.ReplaceAllString(original, rule.replace)
+		case strings.HasPrefix(original, rule.prefix):
+			rewritten = rule.replace + strings.TrimPrefix(original, rule.prefix)
+		default:
+			continue
+		}
+
+		result, err := name.ParseReference(rewritten)
+		if err != nil {
+			return nil, fmt.Errorf("%s rewrote %s to an invalid reference %s: %w", rule.policy, original, rewritten, err)
+		}
+
+		r.record(v1alpha1.RewrittenReference{
+			Reference: original,
+			Rewritten: rewritten,
+			Policy:    rule.policy,
+		})
+
+		return result, nil
+	}
+
+	return ref, nil
+}
+
+func (r *registryRewriter) record(rewritten v1alpha1.RewrittenReference) {
+	for _, existing := range r.status.RewrittenReferences {
+		if existing == rewritten {
+			return
+		}
+	}
+
+	r.status.RewrittenReferences = append(r.status.RewrittenReferences, rewritten)
+}
+
+// qualifiedReference returns the fully qualified reference with Docker Hub images named as
+// docker.io/library/nginx:1.25.
+func qualifiedReference(ref name.Reference) string {
+	qualified := ref.Name()
+	if rest, ok := strings.CutPrefix(qualified, name.DefaultRegistry+"/"); ok {
+		return "docker.io/" + rest
+	}
+
+	return qualified
+}
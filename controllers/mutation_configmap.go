@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// publishConfigMap creates or updates the ConfigMap of obj with the given name and data. An existing
+// ConfigMap which isn't controlled by obj isn't adopted, as it may hold data of a user.
+func (m *MutationReconcileLooper) publishConfigMap(ctx context.Context, obj v1alpha1.MutationObject, name string, data map[string]string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: obj.GetNamespace(),
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, m.Client, cm, func() error {
+		if cm.ResourceVersion != "" && !metav1.IsControlledBy(cm, obj) {
+			return fmt.Errorf("ConfigMap %s already exists and isn't owned by %s", name, obj.GetName())
+		}
+
+		if err := controllerutil.SetControllerReference(obj, cm, m.Scheme); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+
+		cm.Data = data
+
+		return nil
+	})
+
+	return err
+}
+
+// deleteConfigMap deletes the ConfigMap of obj with the given name. A ConfigMap which isn't controlled
+// by obj is left alone.
+func (m *MutationReconcileLooper) deleteConfigMap(ctx context.Context, obj v1alpha1.MutationObject, name string) error {
+	cm := &corev1.ConfigMap{}
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !metav1.IsControlledBy(cm, obj) {
+		return nil
+	}
+
+	if err := m.Client.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
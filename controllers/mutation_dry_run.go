@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+)
+
+// dryRunDiffKey is the key of the ConfigMap holding the unified diffs of a dry run.
+const dryRunDiffKey = "diff.patch"
+
+const (
+	// dryRunFileDiffLimit is the size limit of the diff of a single file.
+	dryRunFileDiffLimit = 16 * 1024
+	// dryRunDiffLimit is the size limit of all diffs, which keeps the ConfigMap below the size limit of objects.
+	dryRunDiffLimit = 512 * 1024
+)
+
+// dryRunContextLines is the number of unchanged lines shown around the changes of a file.
+const dryRunContextLines = 3
+
+// dryRunName returns the name of the ConfigMap publishing the changes of a dry run of obj.
+func dryRunName(obj v1alpha1.MutationObject) string {
+	return obj.GetName() + "-dry-run"
+}
+
+// dryRun compares the files rendered into sourceDir with the files of the current snapshot of obj.
+// The unified diffs of the changes are published in the dry run ConfigMap with the secret values
+// redacted and the content of Secrets omitted, and the changed files are listed in the status.
+func (m *MutationReconcileLooper) dryRun(ctx context.Context, obj v1alpha1.MutationObject, sourceDir string, secrets secretValues) error {
+	current, snapshotDigest, err := m.snapshotFiles(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := readFiles(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to read rendered files: %w", err)
+	}
+
+	changes, diff := diffFiles(current, rendered, secrets)
+
+	if err := m.publishConfigMap(ctx, obj, dryRunName(obj), map[string]string{dryRunDiffKey: diff}); err != nil {
+		return fmt.Errorf("failed to publish dry run: %w", err)
+	}
+
+	obj.GetStatus().DryRun = &v1alpha1.DryRunResult{
+		ConfigMapRef:   meta.LocalObjectReference{Name: dryRunName(obj)},
+		SnapshotDigest: snapshotDigest,
+		Files:          changes,
+	}
+
+	return nil
+}
+
+// removeDryRun removes the dry run ConfigMap of obj and its result from the status once the
+// snapshot has been written.
+func (m *MutationReconcileLooper) removeDryRun(ctx context.Context, obj v1alpha1.MutationObject) error {
+	if obj.GetStatus().DryRun == nil {
+		return nil
+	}
+
+	if err := m.deleteConfigMap(ctx, obj, dryRunName(obj)); err != nil {
+		return fmt.Errorf("failed to delete dry run: %w", err)
+	}
+
+	obj.GetStatus().DryRun = nil
+
+	return nil
+}
+
+// snapshotFiles returns the files of the current snapshot of obj by path together with the digest of
+// the snapshot. No files are returned if the snapshot doesn't exist yet.
+func (m *MutationReconcileLooper) snapshotFiles(ctx context.Context, obj v1alpha1.MutationObject) (map[string][]byte, string, error) {
+	if obj.GetSnapshotName() == "" {
+		return nil, "", nil
+	}
+
+	snapshot := &v1alpha1.Snapshot{}
+	key := types.NamespacedName{
+		Name:      obj.GetSnapshotName(),
+		Namespace: obj.GetNamespace(),
+	}
+	if err := m.Client.Get(ctx, key, snapshot); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, "", nil
+		}
+
+		return nil, "", fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if snapshot.Status.LastReconciledDigest == "" {
+		return nil, "", nil
+	}
+
+	data, err := m.getSnapshotBytes(ctx, snapshot, true)
+	if err != nil {
+		return nil, "", err
+	}
+
+	files, err := readTarFiles(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read snapshot files: %w", err)
+	}
+
+	return files, snapshot.Status.LastReconciledDigest, nil
+}
+
+// readTarFiles returns the regular files of the tar archive by path.
+func readTarFiles(data []byte) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		files[strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(header.Name)), "/")] = content
+	}
+}
+
+// readFiles returns the regular files of dir by path.
+func readFiles(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(rel)] = content
+
+		return nil
+	})
+
+	return files, err
+}
+
+// diffFiles compares the rendered files with the current files and returns the changed files sorted
+// by path together with their unified diffs. The diff of a file is truncated beyond the size limit of
+// a file and omitted if it would exceed the size limit of all diffs.
+func diffFiles(current, rendered map[string][]byte, secrets secretValues) ([]v1alpha1.FileChange, string) {
+	paths := make([]string, 0, len(current)+len(rendered))
+	for p := range current {
+		paths = append(paths, p)
+	}
+
+	for p := range rendered {
+		if _, ok := current[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+
+	sort.Strings(paths)
+
+	var (
+		changes []v1alpha1.FileChange
+		out     strings.Builder
+		omitted int
+	)
+
+	for _, p := range paths {
+		before, inCurrent := current[p]
+		after, inRendered := rendered[p]
+
+		change := v1alpha1.FileChange{Path: p}
+		switch {
+		case !inCurrent:
+			change.Change = v1alpha1.FileAdded
+		case !inRendered:
+			change.Change = v1alpha1.FileRemoved
+		case !bytes.Equal(before, after):
+			change.Change = v1alpha1.FileChanged
+		default:
+			continue
+		}
+
+		diff := secrets.redact(unifiedDiff(p, before, after, inCurrent, inRendered))
+		if len(diff) > dryRunFileDiffLimit {
+			diff = truncateDiff(diff, dryRunFileDiffLimit)
+			change.Truncated = true
+		}
+
+		if out.Len()+len(diff) > dryRunDiffLimit {
+			change.Truncated = true
+			omitted++
+		} else {
+			out.WriteString(diff)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if omitted > 0 {
+		fmt.Fprintf(&out, "... diffs of %d files omitted, the size limit has been reached\n", omitted)
+	}
+
+	return changes, out.String()
+}
+
+// unifiedDiff returns the unified diff of the file. Binary files and files holding Secrets are reported
+// without their content.
+func unifiedDiff(file string, before, after []byte, inBefore, inAfter bool) string {
+	from, to := "a/"+file, "b/"+file
+	if !inBefore {
+		from = "/dev/null"
+	}
+
+	if !inAfter {
+		to = "/dev/null"
+	}
+
+	if isBinary(before) || isBinary(after) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", from, to)
+	}
+
+	// the values of a Secret can't all be known for redaction, e.g. the ones of a kustomize secret generator
+	if containsSecret(before) || containsSecret(after) {
+		return fmt.Sprintf("Files %s and %s holding Secrets differ, content not shown\n", from, to)
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: from,
+		ToFile:   to,
+		Context:  dryRunContextLines,
+	})
+	if err != nil {
+		return fmt.Sprintf("failed to diff %s: %s\n", file, err)
+	}
+
+	return diff
+}
+
+// splitLines splits the content into lines keeping their newlines. The last line is terminated by a
+// newline if it isn't already, so the diffs of the files are kept apart.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+
+	return lines
+}
+
+// truncateDiff truncates the diff to the last complete line within the limit. A diff without a line
+// break within the limit is cut at a rune boundary, so it stays valid UTF-8.
+func truncateDiff(diff string, limit int) string {
+	for limit > 0 && !utf8.RuneStart(diff[limit]) {
+		limit--
+	}
+
+	diff = diff[:limit]
+	if i := strings.LastIndex(diff, "\n"); i >= 0 {
+		diff = diff[:i+1]
+	}
+
+	return diff + "... diff truncated\n"
+}
+
+// secretKindPattern matches the kind of a Secret in YAML or JSON content which can't be decoded.
+var secretKindPattern = regexp.MustCompile(`(?m)^\s*["']?kind["']?\s*:\s*["']?Secret["']?\s*,?\s*$`)
+
+// containsSecret reports whether the content holds a document of kind Secret. Content which can't be
+// decoded, like templates, is searched for the kind instead.
+func containsSecret(content []byte) bool {
+	docs, err := decodeDocuments(content)
+	if err != nil {
+		return secretKindPattern.Match(content)
+	}
+
+	for _, doc := range docs {
+		if lookupScalar(doc, "kind") == "Secret" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBinary reports whether the content isn't text.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -23,6 +24,8 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/mandelsoft/spiff/spiffing"
 	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
 	"go.podman.io/image/v5/pkg/compression"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -44,9 +47,6 @@ import (
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
-	"ocm.software/ocm/api/ocm/extensions/accessmethods/localblob"
-	"ocm.software/ocm/api/ocm/extensions/accessmethods/ociartifact"
-	"ocm.software/ocm/api/ocm/extensions/accessmethods/ociblob"
 	"ocm.software/ocm/api/ocm/ocmutils/localize"
 	ocmruntime "ocm.software/ocm/api/utils/runtime"
 	"ocm.software/ocm/api/utils/spiff"
@@ -55,12 +55,16 @@ import (
 	"github.com/open-component-model/ocm-controller/pkg/cache"
 	"github.com/open-component-model/ocm-controller/pkg/component"
 	"github.com/open-component-model/ocm-controller/pkg/configdata"
+	"github.com/open-component-model/ocm-controller/pkg/helm"
 	"github.com/open-component-model/ocm-controller/pkg/ocm"
 )
 
 // errTar defines an error that occurs when the resource is not a tar archive.
 var errTar = errors.New("expected tarred directory content for configuration/localization resources, got plain text")
 
+// defaultValuesFile is the values file read from the archive produced by the source ref of a values source.
+const defaultValuesFile = "values.yaml"
+
 // MutationReconcileLooper holds dependencies required to reconcile a mutation object.
 type MutationReconcileLooper struct {
 	Scheme         *runtime.Scheme
@@ -69,9 +73,15 @@ type MutationReconcileLooper struct {
 	Cache          cache.Cache
 	DynamicClient  dynamic.Interface
 	SnapshotWriter snapshot.Writer
+	ChartRenderer  helm.Renderer
+
+	// APIReader reads ConfigMaps and Secrets directly from the API server, so they aren't cached
+	// cluster-wide. The configuration controller only watches their metadata.
+	APIReader client.Reader
 }
 
-// ReconcileMutationObject reconciles mutation objects and writes a snapshot to the cache.
+// ReconcileMutationObject reconciles mutation objects and writes a snapshot to the cache. A dry run
+// publishes the changes to the snapshot instead of writing it.
 func (m *MutationReconcileLooper) ReconcileMutationObject(ctx context.Context, obj v1alpha1.MutationObject) (int64, error) {
 	mutationSpec := obj.GetSpec()
 
@@ -91,103 +101,248 @@ func (m *MutationReconcileLooper) ReconcileMutationObject(ctx context.Context, o
 		return -1, fmt.Errorf("source resource data cannot be empty")
 	}
 
-	sourceDir, snapshotID, err := m.performMutation(ctx, obj, mutationSpec, sourceData)
+	sourceDir, snapshotID, configDigest, secrets, err := m.performMutation(ctx, obj, mutationSpec, sourceData)
 	if err != nil {
 		return -1, err
 	}
 
 	defer os.RemoveAll(sourceDir)
 
-	digest, size, err := m.SnapshotWriter.Write(ctx, obj, sourceDir, snapshotID)
+	// the snapshot is left untouched by a dry run, so the dependents keep using the last rendering
+	if mutationSpec.DryRun {
+		return 0, m.dryRun(ctx, obj, sourceDir, secrets)
+	}
+
+	componentName, componentVersion := m.getComponentNameAndVersion(ctx, mutationSpec)
+	provenance := snapshot.Provenance{
+		ComponentName:    componentName,
+		ComponentVersion: componentVersion,
+		SourceDigest:     digest.FromBytes(sourceData).String(),
+		ConfigDigest:     configDigest,
+	}
+
+	snapshotDigest, size, err := m.SnapshotWriter.Write(ctx, obj, sourceDir, snapshotID, provenance)
 	if err != nil {
 		return -1, fmt.Errorf("error writing snapshot: %w", err)
 	}
 
-	obj.GetStatus().LatestSnapshotDigest = digest
+	obj.GetStatus().LatestSnapshotDigest = snapshotDigest
+
+	if err := m.removeDryRun(ctx, obj); err != nil {
+		return -1, err
+	}
 
 	return size, nil
 }
 
+// performMutation applies the configured mutation and returns the directory holding the result,
+// the identity of the snapshot, the digest of the configuration that has been applied and the
+// secret values which must not be revealed from the result.
 func (m *MutationReconcileLooper) performMutation(
 	ctx context.Context,
 	obj v1alpha1.MutationObject,
 	mutationSpec *v1alpha1.MutationSpec,
 	sourceData []byte,
-) (string, ocmmetav1.Identity, error) {
+) (string, ocmmetav1.Identity, string, secretValues, error) {
 	var (
-		snapshotID ocmmetav1.Identity
-		sourceDir  string
-		err        error
+		snapshotID   ocmmetav1.Identity
+		sourceDir    string
+		configDigest string
+		secrets      secretValues
+		err          error
 	)
 
-	if mutationSpec.ConfigRef != nil {
-		sourceDir, snapshotID, err = m.mutateConfigRef(ctx, obj, mutationSpec, sourceData)
+	obj.GetStatus().RewrittenReferences = nil
+	obj.GetStatus().ValuesSources = nil
+	obj.GetStatus().ValuesValidationErrors = nil
+
+	// objects created before the strategies were validated by the API server might still set several of them.
+	if strategies := mutationStrategies(mutationSpec); len(strategies) > 1 {
+		return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("only one mutation strategy can be set, found %s", strings.Join(strategies, ", "))
+	}
+
+	switch {
+	case mutationSpec.ConfigRef != nil:
+		sourceDir, snapshotID, configDigest, secrets, err = m.mutateConfigRef(ctx, obj, mutationSpec, sourceData)
+		if err != nil {
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to apply config ref: %w", err)
+		}
+	case mutationSpec.PatchStrategicMerge != nil:
+		sourceDir, snapshotID, secrets, err = m.mutatePatchStrategicMerge(ctx, obj, mutationSpec, sourceData)
 		if err != nil {
-			return "", ocmmetav1.Identity{}, fmt.Errorf("failed to apply config ref: %w", err)
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to apply patch strategic merge strategy: %w", err)
 		}
+
+		configDigest = snapshotID[v1alpha1.SourceArtifactChecksumKey]
+	case len(mutationSpec.PatchJSON6902) > 0:
+		sourceDir, snapshotID, err = m.mutateJSON6902(ctx, obj, mutationSpec, sourceData)
+		if err != nil {
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to apply json6902 patch strategy: %w", err)
+		}
+
+		configDigest = snapshotID[v1alpha1.SourceArtifactChecksumKey]
+	case len(mutationSpec.YQ) > 0:
+		sourceDir, snapshotID, err = m.mutateYQ(ctx, obj, mutationSpec, sourceData)
+		if err != nil {
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to apply yq strategy: %w", err)
+		}
+
+		configDigest = snapshotID[v1alpha1.SourceArtifactChecksumKey]
+	case mutationSpec.Kustomize != nil:
+		sourceDir, snapshotID, err = m.mutateKustomize(ctx, obj, mutationSpec, sourceData)
+		if err != nil {
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to apply kustomize overlay: %w", err)
+		}
+
+		configDigest = snapshotID[v1alpha1.SourceArtifactChecksumKey]
+	case mutationSpec.HelmTemplate != nil:
+		sourceDir, snapshotID, secrets, err = m.mutateHelmTemplate(ctx, obj, mutationSpec, sourceData)
+		if err != nil {
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to render helm chart: %w", err)
+		}
+
+		configDigest = snapshotID[v1alpha1.SourceArtifactChecksumKey]
 	}
 
-	if mutationSpec.PatchStrategicMerge != nil {
-		sourceDir, snapshotID, err = m.mutatePatchStrategicMerge(ctx, obj, mutationSpec, sourceData)
+	// automatic localization is applied on top of the localization rules of the config ref
+	if mutationSpec.AutoLocalize != nil {
+		var id ocmmetav1.Identity
+		sourceDir, id, err = m.mutateAutoLocalize(ctx, obj, mutationSpec, sourceData, sourceDir)
 		if err != nil {
-			return "", ocmmetav1.Identity{}, fmt.Errorf("failed to apply patch strategic merge strategy: %w", err)
+			return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to localize images: %w", err)
+		}
+
+		if id != nil {
+			snapshotID = id
+			configDigest = snapshotID[v1alpha1.SourceArtifactChecksumKey]
 		}
+	} else {
+		obj.GetStatus().LocalizedImages = nil
+		obj.GetStatus().UnmatchedImages = nil
 	}
 
-	return sourceDir, snapshotID, nil
+	return sourceDir, snapshotID, configDigest, secrets, nil
+}
+
+// mutationStrategies returns the names of the mutation strategies set in the mutation spec.
+func mutationStrategies(spec *v1alpha1.MutationSpec) []string {
+	var strategies []string
+	for _, strategy := range []struct {
+		name string
+		set  bool
+	}{
+		{"configRef", spec.ConfigRef != nil},
+		{"patchStrategicMerge", spec.PatchStrategicMerge != nil},
+		{"patchJSON6902", len(spec.PatchJSON6902) > 0},
+		{"yq", len(spec.YQ) > 0},
+		{"kustomize", spec.Kustomize != nil},
+		{"helmTemplate", spec.HelmTemplate != nil},
+	} {
+		if strategy.set {
+			strategies = append(strategies, strategy.name)
+		}
+	}
+
+	return strategies
+}
+
+// getComponentNameAndVersion returns the name and version of the first component version
+// referenced by the mutation spec. Snapshot sources don't carry this information, in which
+// case empty values are returned.
+func (m *MutationReconcileLooper) getComponentNameAndVersion(ctx context.Context, spec *v1alpha1.MutationSpec) (string, string) {
+	for _, ref := range []*v1alpha1.ObjectReference{&spec.SourceRef, spec.ConfigRef} {
+		if ref == nil || ref.Kind != v1alpha1.ComponentVersionKind {
+			continue
+		}
+
+		cv, err := m.getComponentVersion(ctx, ref)
+		if err != nil {
+			log.FromContext(ctx).V(v1alpha1.LevelDebug).Info("failed to get component version for provenance", "error", err)
+
+			continue
+		}
+
+		return cv.Spec.Component, cv.Status.ReconciledVersion
+	}
+
+	return "", ""
 }
 
 func (m *MutationReconcileLooper) configure(
 	ctx context.Context,
+	obj v1alpha1.MutationObject,
 	data, configObj []byte,
 	mutationSpec *v1alpha1.MutationSpec,
-	namespace, name string,
-) (string, error) {
-	configValues, err := m.getValues(ctx, mutationSpec, namespace, name)
+	configSecrets secretValues,
+) (_ string, _ secretValues, err error) {
+	configValues, secrets, err := m.getValues(ctx, obj, mutationSpec)
 	if err != nil {
-		return "", fmt.Errorf("failed to get values: %w", err)
+		return "", nil, fmt.Errorf("failed to get values: %w", err)
 	}
 
+	secrets = secrets.merge(configSecrets)
+
+	// the validation, substitution and rendering errors may quote the values
+	defer func() {
+		err = secrets.redactError(err)
+	}()
+
 	log := log.FromContext(ctx)
 
 	virtualFS, err := osfs.NewTempFileSystem()
 	if err != nil {
-		return "", fmt.Errorf("fs error: %w", err)
+		return "", nil, fmt.Errorf("fs error: %w", err)
 	}
 
+	// the source is configured with the secret values, so it's removed right away if the mutation fails
+	defer func() {
+		if err != nil {
+			vfs.Cleanup(virtualFS)
+		}
+	}()
+
 	fi, err := virtualFS.Stat("/")
 	if err != nil {
-		return "", fmt.Errorf("fs error: %w", err)
+		return "", nil, fmt.Errorf("fs error: %w", err)
 	}
 
 	sourceDir := filepath.Join(os.TempDir(), fi.Name())
 
 	if !isTar(data) {
-		return "", errTar
+		return "", nil, errTar
 	}
 
 	if err := tarutils.ExtractTarToFs(virtualFS, bytes.NewBuffer(data)); err != nil {
-		return "", fmt.Errorf("extract tar error: %w", err)
+		return "", nil, fmt.Errorf("extract tar error: %w", err)
 	}
 
-	rules, err := m.createSubstitutionRulesForConfigurationValues(configObj, configValues)
+	if err := m.checkValues(ctx, obj, configObj, configValues, mutationSpec.Profile, secrets); err != nil {
+		return "", nil, err
+	}
+
+	rules, err := m.createSubstitutionRulesForConfigurationValues(configObj, configValues, mutationSpec.Profile)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	if len(rules) == 0 {
 		log.Info("no rules generated from the available config data; the generate snapshot will have no modifications")
 	}
 
-	if err := localize.Substitute(rules, virtualFS); err != nil {
-		return "", fmt.Errorf("localization substitution failed: %w", err)
+	if err := substitute(sourceDir, virtualFS, rules); err != nil {
+		return "", nil, fmt.Errorf("localization substitution failed: %w", err)
 	}
 
-	return sourceDir, nil
+	if err := m.renderConfiguration(ctx, configObj, configValues, mutationSpec, sourceDir); err != nil {
+		return "", nil, fmt.Errorf("failed to render configuration: %w", err)
+	}
+
+	return sourceDir, secrets, nil
 }
 
 func (m *MutationReconcileLooper) localize(
 	ctx context.Context,
+	obj v1alpha1.MutationObject,
 	mutationSpec *v1alpha1.MutationSpec,
 	data, configObj []byte,
 ) (string, error) {
@@ -227,7 +382,7 @@ func (m *MutationReconcileLooper) localize(
 		return "", fmt.Errorf("extract tar error: %w", err)
 	}
 
-	rules, err := m.createSubstitutionRulesForLocalization(ctx, cv, configObj, refPath)
+	rules, err := m.createSubstitutionRulesForLocalization(ctx, obj, cv, configObj, refPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to create substitution rules for localization: %w", err)
 	}
@@ -236,7 +391,15 @@ func (m *MutationReconcileLooper) localize(
 		logger.Info("no rules generated from the available config data; the generate snapshot will have no modifications")
 	}
 
-	if err := localize.Substitute(rules, virtualFS); err != nil {
+	if mutationSpec.LocalizationOutput == v1alpha1.KustomizeImagesOutput {
+		if err := kustomizeImages(sourceDir, rules); err != nil {
+			return "", fmt.Errorf("failed to generate kustomize images: %w", err)
+		}
+
+		return sourceDir, nil
+	}
+
+	if err := substitute(sourceDir, virtualFS, rules); err != nil {
 		return "", fmt.Errorf("localization substitution failed: %w", err)
 	}
 
@@ -366,10 +529,11 @@ func (m *MutationReconcileLooper) getSnapshotBytes(ctx context.Context, snapshot
 
 func (m *MutationReconcileLooper) createSubstitutionRulesForLocalization(
 	ctx context.Context,
+	obj v1alpha1.MutationObject,
 	cv *v1alpha1.ComponentVersion,
 	data []byte,
 	refPath []ocmmetav1.Identity,
-) (localize.Substitutions, error) {
+) (substitutions, error) {
 	config := &configdata.ConfigData{}
 	if err := ocmruntime.DefaultYAMLEncoding.Unmarshal(data, config); err != nil {
 		return nil,
@@ -390,8 +554,19 @@ func (m *MutationReconcileLooper) createSubstitutionRulesForLocalization(
 	}
 	defer compvers.Close()
 
-	var localizations localize.Substitutions
-	for _, l := range config.Localization {
+	resolver := newDigestResolver(ctx, octx, m.OCMClient, obj.GetStatus().ResolvedDigests)
+
+	rewriter, err := m.registryRewriter(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var result substitutions
+	for i, l := range config.Localization {
+		var (
+			localizations localize.Substitutions
+			image         string
+		)
 		if l.Mapping != nil {
 			res, err := m.compileMapping(ctx, cv, l.Mapping.Transform)
 			if err != nil {
@@ -401,39 +576,115 @@ func (m *MutationReconcileLooper) createSubstitutionRulesForLocalization(
 			if err := localizations.Add("custom", l.File, l.Mapping.Path, res); err != nil {
 				return nil, fmt.Errorf("failed to add identifier: %w", err)
 			}
-
-			continue
+		} else if image, err = m.performLocalization(ctx, octx, obj, l, &localizations, refPath, compvers, resolver, rewriter); err != nil {
+			return nil, fmt.Errorf("failed to perform localization: %w", err)
 		}
 
-		if err := m.performLocalization(octx, l, &localizations, refPath, compvers); err != nil {
-			return nil, fmt.Errorf("failed to perform localization: %w", err)
+		subs := targetSubstitutions(fmt.Sprintf("localization rule %d", i), localizations, l.Select, l.Strict)
+		for j := range subs {
+			subs[j].image = image
 		}
+
+		result = append(result, subs...)
 	}
 
-	return localizations, nil
+	obj.GetStatus().ResolvedDigests = resolver.resolved
+
+	return result, nil
 }
 
+// performLocalization adds the substitutions of the localization rule and returns the image the
+// resource of the rule resolves to, which is empty if the resource isn't an OCI artifact.
 func (m *MutationReconcileLooper) performLocalization(
+	ctx context.Context,
 	octx ocmcore.Context,
+	obj v1alpha1.MutationObject,
 	l configdata.LocalizationRule,
 	localizations *localize.Substitutions,
 	refPath []ocmmetav1.Identity,
 	compvers ocmcore.ComponentVersionAccess,
-) error {
-	pRef, err := resolveReference(l, refPath, compvers, octx)
+	resolver *digestResolver,
+	rewriter *registryRewriter,
+) (string, error) {
+	if l.Snapshot != nil {
+		location, err := m.snapshotLocation(ctx, l.Snapshot, obj.GetNamespace())
+		if err != nil {
+			return "", err
+		}
+
+		return "", addLocation(l, location, localizations)
+	}
+
+	location, resource, err := resolveLocation(l, refPath, compvers, octx)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if location.Reference != nil {
+		rewritten, err := rewriter.rewrite(location.Reference)
+		if err != nil {
+			return "", err
+		}
+
+		if location, err = ociLocation(rewritten.Name()); err != nil {
+			return "", err
+		}
+	}
+
+	if err := addLocation(l, location, localizations); err != nil {
+		return "", err
+	}
+
+	pRef := location.Reference
+	if pRef == nil {
+		if l.Registry != "" || l.Repository != "" || l.FullyQualifiedRepository != "" || l.Image != "" ||
+			l.Tag != "" || l.Digest != "" || l.PinDigest || l.Platform != nil {
+			return "", fmt.Errorf("resource %s is not an OCI artifact, only url, host, path and ref can be localized", l.Resource.Name)
+		}
+
+		return "", nil
+	}
+
+	image := pRef.Name()
+	if platform := localizationPlatform(l, obj.GetSpec(), resource.Meta().GetType()); platform != nil {
+		dgst, err := resolver.resolvePlatform(pRef, *platform)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve platform %s of %s: %w", platform, pRef, err)
+		}
+
+		if l.Digest != "" {
+			if err := localizations.Add("digest", l.File, l.Digest, dgst); err != nil {
+				return "", fmt.Errorf("failed to add digest: %w", err)
+			}
+		}
+
+		image = pRef.Context().Digest(dgst).Name()
+	} else if l.Digest != "" || l.PinDigest {
+		dgst, err := resolver.resolve(pRef, resource.Meta().Digest)
+		if err != nil {
+			return "", fmt.Errorf("failed to pin digest of %s: %w", pRef, err)
+		}
+
+		if l.Digest != "" {
+			if err := localizations.Add("digest", l.File, l.Digest, dgst); err != nil {
+				return "", fmt.Errorf("failed to add digest: %w", err)
+			}
+		}
+
+		if l.PinDigest {
+			image = pRef.Context().Digest(dgst).Name()
+		}
 	}
 
 	if l.Registry != "" {
 		if err := localizations.Add("registry", l.File, l.Registry, pRef.Context().Registry.Name()); err != nil {
-			return fmt.Errorf("failed to add registry: %w", err)
+			return "", fmt.Errorf("failed to add registry: %w", err)
 		}
 	}
 
 	if l.Repository != "" {
 		if err := localizations.Add("repository", l.File, l.Repository, pRef.Context().RepositoryStr()); err != nil {
-			return fmt.Errorf("failed to add repository: %w", err)
+			return "", fmt.Errorf("failed to add repository: %w", err)
 		}
 	}
 
@@ -441,92 +692,94 @@ func (m *MutationReconcileLooper) performLocalization(
 		ctxt := pRef.Context()
 		if err := localizations.Add("fullyQualifiedRepository", l.File, l.FullyQualifiedRepository,
 			ctxt.Registry.Name()+"/"+ctxt.RepositoryStr()); err != nil {
-			return fmt.Errorf("failed to add repository: %w", err)
+			return "", fmt.Errorf("failed to add repository: %w", err)
 		}
 	}
 
 	if l.Image != "" {
-		if err := localizations.Add("image", l.File, l.Image, pRef.Name()); err != nil {
-			return fmt.Errorf("failed to add image ref name: %w", err)
+		if err := localizations.Add("image", l.File, l.Image, image); err != nil {
+			return "", fmt.Errorf("failed to add image ref name: %w", err)
 		}
 	}
 
 	if l.Tag != "" {
 		if err := localizations.Add("tag", l.File, l.Tag, pRef.Identifier()); err != nil {
-			return fmt.Errorf("failed to add identifier: %w", err)
+			return "", fmt.Errorf("failed to add identifier: %w", err)
 		}
 	}
 
-	return nil
+	return image, nil
 }
 
-func resolveReference(
+func resolveLocation(
 	l configdata.LocalizationRule,
 	refPath []ocmmetav1.Identity,
 	compvers ocmcore.ComponentVersionAccess,
 	octx ocmcore.Context,
-) (name.Reference, error) {
+) (*resourceLocation, ocmcore.ResourceAccess, error) {
 	resourceRef := ocmmetav1.NewNestedResourceRef(ocmmetav1.NewIdentity(l.Resource.Name), refPath)
 
 	resource, _, err := resourcerefs.ResolveResourceReference(compvers, resourceRef, compvers.Repository())
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch resource from component version: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch resource from component version: %w", err)
 	}
 
 	accSpec, err := resource.Access()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	var (
-		ref    string
-		refErr error
-	)
-
-	for ref == "" && refErr == nil {
-		switch x := accSpec.(type) {
-		case *ociartifact.AccessSpec:
-			ref = x.ImageReference
-		case *ociblob.AccessSpec:
-			ref = fmt.Sprintf("%s@%s", x.Reference, x.Digest)
-		case *localblob.AccessSpec:
-			if x.GlobalAccess == nil {
-				refErr = errors.New("cannot determine image digest")
-			} else {
-				accSpec, refErr = octx.AccessSpecForSpec(x.GlobalAccess)
-			}
-		default:
-			refErr = errors.New("cannot determine access spec type")
-		}
+	location, err := accessLocation(octx, accSpec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve location of resource %s: %w", l.Resource.Name, err)
 	}
 
-	if refErr != nil {
-		return nil, fmt.Errorf("failed to parse access reference: %w", refErr)
+	return location, resource, nil
+}
+
+// accessReference returns the image reference the access of the resource points to.
+func accessReference(octx ocmcore.Context, resource ocmcore.ResourceAccess) (name.Reference, error) {
+	accSpec, err := resource.Access()
+	if err != nil {
+		return nil, err
 	}
 
-	pRef, err := name.ParseReference(ref)
+	location, err := accessLocation(octx, accSpec)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse access reference: %w", err)
 	}
 
-	return pRef, nil
+	if location.Reference == nil {
+		return nil, fmt.Errorf("failed to parse access reference: %s is not an OCI artifact", location.URL)
+	}
+
+	return location.Reference, nil
 }
 
 func (m *MutationReconcileLooper) createSubstitutionRulesForConfigurationValues(
 	data []byte,
 	values *apiextensionsv1.JSON,
-) (localize.Substitutions, error) {
+	profile string,
+) (substitutions, error) {
 	config := &configdata.ConfigData{}
 	if err := ocmruntime.DefaultYAMLEncoding.Unmarshal(data, config); err != nil {
 		return nil,
 			fmt.Errorf("failed to unmarshal content: %w", err)
 	}
 
+	if err := applyProfile(config, profile); err != nil {
+		return nil, err
+	}
+
 	var rules localize.Substitutions
+	ruleIndex := make(map[string]int, len(config.Configuration.Rules))
 	for i, l := range config.Configuration.Rules {
-		if err := rules.Add(fmt.Sprintf("subst-%d", i), l.File, l.Path, l.Value); err != nil {
+		name := fmt.Sprintf("subst-%d", i)
+		if err := rules.Add(name, l.File, l.Path, l.Value); err != nil {
 			return nil, fmt.Errorf("failed to add rule: %w", err)
 		}
+
+		ruleIndex[name] = i
 	}
 
 	defaults, err := json.Marshal(config.Configuration.Defaults)
@@ -534,22 +787,30 @@ func (m *MutationReconcileLooper) createSubstitutionRulesForConfigurationValues(
 		return nil, fmt.Errorf("failed to marshal configuration defaults: %w", err) //nolint:staticcheck // it's fine
 	}
 
-	schema, err := json.Marshal(config.Configuration.Schema) //nolint:staticcheck // it's fine
+	// the values have been validated against the schema of the config data by checkValues
+	configSubstitutions, err := m.generateSubstitutions(rules, defaults, values.Raw)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal configuration schema: %w", err)
+		return nil, fmt.Errorf("configurator error: %w", err)
 	}
 
-	configSubstitutions, err := m.generateSubstitutions(rules, defaults, values.Raw, schema)
-	if err != nil {
-		return nil, fmt.Errorf("configurator error: %w", err)
+	// the substitutions keep the names of the rules they were generated from
+	var result substitutions
+	for _, sub := range configSubstitutions {
+		i, ok := ruleIndex[sub.Name]
+		if !ok {
+			return nil, fmt.Errorf("configurator error: unknown substitution %s", sub.Name)
+		}
+
+		rule := config.Configuration.Rules[i]
+		result = append(result, targetSubstitutions(fmt.Sprintf("configuration rule %d", i), localize.Substitutions{sub}, rule.Select, rule.Strict)...)
 	}
 
-	return configSubstitutions, nil
+	return result, nil
 }
 
 func (m *MutationReconcileLooper) generateSubstitutions(
 	subst []localize.Substitution,
-	defaults, configValues, schema []byte,
+	defaults, configValues []byte,
 ) (localize.Substitutions, error) {
 	var err error
 	var spiffTemplateDoc *spiffTemplateDoc
@@ -567,12 +828,6 @@ func (m *MutationReconcileLooper) generateSubstitutions(
 		return nil, err
 	}
 
-	if len(schema) > 0 {
-		if err := spiff.ValidateByScheme(configValues, schema); err != nil {
-			return nil, fmt.Errorf("validation failed: %w", err)
-		}
-	}
-
 	config, err := spiff.CascadeWith(spiff.TemplateData(ocmAdjustmentsTemplateKey, spiffTemplateBytes), spiff.Mode(spiffing.MODE_PRIVATE))
 	if err != nil {
 		return nil, fmt.Errorf("error while doing cascade with: %w", err)
@@ -874,105 +1129,276 @@ func (m *MutationReconcileLooper) getComponentVersion(ctx context.Context, obj *
 	return cv, nil
 }
 
-// getValues returns values that can be used for the configuration
-// currently it only possible to use inline values OR values from an external source.
+// getValues returns the values that can be used for the configuration. The values of the sources are
+// deep merged in the order of ValuesFrom, followed by the inline values. The digest of the values of
+// each source is recorded in the status of the object. Values read from a Secret are returned as
+// secret values which have to be redacted.
 func (m *MutationReconcileLooper) getValues(
-	ctx context.Context, obj *v1alpha1.MutationSpec, namespace, name string,
-) (*apiextensionsv1.JSON, error) {
-	if obj.Values != nil {
-		return obj.Values, nil
+	ctx context.Context, obj v1alpha1.MutationObject, spec *v1alpha1.MutationSpec,
+) (*apiextensionsv1.JSON, secretValues, error) {
+	if len(spec.ValuesFrom) == 0 {
+		if spec.Values != nil {
+			return spec.Values, nil, nil
+		}
+
+		return nil, nil, errors.New("no values found")
+	}
+
+	decryptor, err := m.getDecryptor(ctx, obj.GetNamespace(), spec.Decryption)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	var data map[string]any
+	var (
+		values  = make(map[string]any)
+		secrets secretValues
+	)
+	for i := range spec.ValuesFrom {
+		source := &spec.ValuesFrom[i]
+
+		data, sourceSecrets, record, err := m.fromValuesSource(ctx, source, decryptor, obj.GetNamespace(), obj.GetName())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		secrets = append(secrets, sourceSecrets...)
+
+		if data == nil {
+			continue
+		}
+
+		content, err := json.Marshal(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal values: %w", err)
+		}
+
+		record.Digest = digest.FromBytes(content).String()
+		obj.GetStatus().ValuesSources = append(obj.GetStatus().ValuesSources, record)
+
+		values = mergeValues(values, data)
+	}
+
+	if spec.Values != nil {
+		var inline map[string]any
+		if err := json.Unmarshal(spec.Values.Raw, &inline); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal inline values: %w", err)
+		}
+
+		values = mergeValues(values, inline)
+	}
+
+	jsonData, err := json.Marshal(values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal values: %w", err)
+	}
+
+	// secret values are sorted again so that values containing values of other secrets are redacted as a whole
+	sortSecretValues(secrets)
+
+	return &apiextensionsv1.JSON{
+		Raw: jsonData,
+	}, secrets, nil
+}
+
+// fromValuesSource returns the values of the source together with the record of the source for the
+// status. The values are nil for optional sources which weren't found. SOPS encrypted values of Flux
+// sources are decrypted by the decryptor and returned as secret values.
+func (m *MutationReconcileLooper) fromValuesSource(
+	ctx context.Context,
+	source *v1alpha1.ValuesSource,
+	decryptor *decryptor,
+	namespace, name string,
+) (map[string]any, secretValues, v1alpha1.ValuesSourceDigest, error) {
+	var record v1alpha1.ValuesSourceDigest
+
 	switch {
-	case obj.ValuesFrom.FluxSource != nil:
-		content, err := m.fromFluxSource(ctx, obj)
+	case source.FluxSource != nil:
+		ref := source.FluxSource.SourceRef
+		content, secrets, version, err := m.fromFluxSource(ctx, source.FluxSource, decryptor)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get values from flux source: %w", err)
+			return nil, nil, record, fmt.Errorf("failed to get values from flux source: %w", err)
 		}
-		data = content
-	case obj.ValuesFrom.ConfigMapSource != nil:
-		content, err := m.fromConfigMapSource(ctx, obj, namespace, name)
+
+		record.Source, record.Version = valuesSourceName(ref.Kind, ref.Namespace, namespace, ref.Name), version
+
+		return content, secrets, record, nil
+	case source.ConfigMapSource != nil:
+		content, version, err := m.fromConfigMapSource(ctx, source.ConfigMapSource, namespace, name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get values from configmap source: %w", err)
+			return nil, nil, record, fmt.Errorf("failed to get values from configmap source: %w", err)
 		}
-		data = content
-	case obj.ValuesFrom.SourceRef != nil:
-		content, err := m.getData(ctx, obj.ValuesFrom.SourceRef)
+
+		record.Source, record.Version = valuesSourceName("ConfigMap", source.ConfigMapSource.Namespace, namespace, source.ConfigMapSource.SourceRef.Name), version
+
+		return content, nil, record, nil
+	case source.SecretSource != nil:
+		content, secrets, version, err := m.fromSecretSource(ctx, source.SecretSource, namespace, name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get values from source ref: %w", err)
+			return nil, nil, record, fmt.Errorf("failed to get values from secret source: %w", err)
 		}
 
-		if err := yaml.Unmarshal(content, &data); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal values: %w", err)
+		record.Source, record.Version = valuesSourceName("Secret", source.SecretSource.Namespace, namespace, source.SecretSource.SourceRef.Name), version
+
+		return content, secrets, record, nil
+	case source.SourceRef != nil:
+		ref := source.SourceRef
+		content, version, err := m.fromSourceRef(ctx, source, namespace, name)
+		if err != nil {
+			return nil, nil, record, fmt.Errorf("failed to get values from source ref: %w", err)
 		}
+
+		record.Source, record.Version = valuesSourceName(ref.Kind, ref.Namespace, namespace, ref.Name), version
+
+		return content, nil, record, nil
 	}
 
-	if data != nil {
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal values: %w", err)
+	return nil, nil, record, errors.New("values source has no source set")
+}
+
+// valuesSourceName returns the name a values source is recorded with in the status.
+func valuesSourceName(kind, namespace, defaultNamespace, name string) string {
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+func (m *MutationReconcileLooper) fromSourceRef(
+	ctx context.Context,
+	source *v1alpha1.ValuesSource,
+	namespace, name string,
+) (map[string]any, string, error) {
+	var (
+		content []byte
+		version string
+		err     error
+	)
+	if source.SourceRef.Kind == v1alpha1.ComponentVersionKind {
+		content, err = m.fetchDataFromComponentVersion(ctx, source.SourceRef)
+	} else {
+		content, version, err = m.fetchDataFromObjectReference(ctx, source.SourceRef, true)
+	}
+	if err != nil {
+		if source.Optional && apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Info("optional source ref not found for Configuration", "namespace", namespace, "configuration", name, "source", source.SourceRef.Name)
+
+			return nil, "", nil
 		}
 
-		return &apiextensionsv1.JSON{
-			Raw: jsonData,
-		}, nil
+		return nil, "", fmt.Errorf("failed to fetch values data: %w", err)
 	}
 
-	return nil, errors.New("no values found")
+	if isTar(content) {
+		if content, err = readValuesFile(content, source.Path); err != nil {
+			return nil, "", err
+		}
+	}
+
+	data := make(map[string]any)
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal values: %w", err)
+	}
+
+	if source.SubPath != "" {
+		var found bool
+		data, found = extractSubpath(data, source.SubPath)
+		if !found {
+			return nil, "", errors.New("subPath not found")
+		}
+	}
+
+	return data, version, nil
+}
+
+// readValuesFile returns the values file at file, values.yaml by default, of the archive produced
+// by the source ref of a values source.
+func readValuesFile(archive []byte, file string) ([]byte, error) {
+	if file == "" {
+		file = defaultValuesFile
+	}
+
+	files, err := readTarFiles(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values archive: %w", err)
+	}
+
+	content, ok := files[strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(file)), "/")]
+	if !ok {
+		return nil, fmt.Errorf("values file %s not found in source ref", file)
+	}
+
+	return content, nil
 }
 
 func (m *MutationReconcileLooper) fromConfigMapSource(
 	ctx context.Context,
-	obj *v1alpha1.MutationSpec,
+	source *v1alpha1.ConfigMapSource,
 	namespace, name string,
-) (map[string]any, error) {
+) (map[string]any, string, error) {
 	data := make(map[string]any)
 	cm := &corev1.ConfigMap{}
 	key := types.NamespacedName{
-		Name:      obj.ValuesFrom.ConfigMapSource.SourceRef.Name,
+		Name:      source.SourceRef.Name,
 		Namespace: namespace,
 	}
-	if err := m.Client.Get(ctx, key, cm); err != nil {
-		if obj.ValuesFrom.ConfigMapSource.Optional && apierrors.IsNotFound(err) {
-			log.FromContext(ctx).Info("optional configmap not found for Configuration", "namespace", namespace, "configuration", name, "configmap", key.Name)
+	if source.Namespace != "" {
+		key.Namespace = source.Namespace
+	}
+	if err := m.APIReader.Get(ctx, key, cm); err != nil {
+		if source.Optional && apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Info("optional configmap not found for Configuration", "namespace", namespace, "configuration", name, "configmap", key.String())
 
-			return data, nil
+			return nil, "", nil
 		}
 
-		return nil, fmt.Errorf("failed to get configmap: %w", err)
+		return nil, "", fmt.Errorf("failed to get configmap: %w", err)
 	}
 
-	content, found := cm.Data[obj.ValuesFrom.ConfigMapSource.Key]
+	if err := m.checkValuesSourceGrant(ctx, "ConfigMap", cm, namespace); err != nil {
+		return nil, "", err
+	}
+
+	content, found := cm.Data[source.Key]
 	if !found {
-		return nil, fmt.Errorf("key %s not found in configmap %s", obj.ValuesFrom.ConfigMapSource.Key, obj.ValuesFrom.ConfigMapSource.SourceRef.Name)
+		return nil, "", fmt.Errorf("key %s not found in configmap %s", source.Key, source.SourceRef.Name)
 	}
 
 	err := yaml.Unmarshal([]byte(content), &data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal values: %w", err)
+		return nil, "", fmt.Errorf("failed to unmarshal values: %w", err)
 	}
 
-	if obj.ValuesFrom.ConfigMapSource.SubPath != "" {
-		data, found = extractSubpath(data, obj.ValuesFrom.ConfigMapSource.SubPath)
+	if source.SubPath != "" {
+		data, found = extractSubpath(data, source.SubPath)
 		if !found {
-			return nil, errors.New("subPath not found")
+			return nil, "", errors.New("subPath not found")
 		}
 	}
 
-	return data, nil
+	return data, cm.ResourceVersion, nil
 }
 
-func (m *MutationReconcileLooper) fromFluxSource(ctx context.Context, obj *v1alpha1.MutationSpec) (map[string]any, error) {
+func (m *MutationReconcileLooper) fromFluxSource(
+	ctx context.Context,
+	valuesSource *v1alpha1.FluxValuesSource,
+	decryptor *decryptor,
+) (map[string]any, secretValues, string, error) {
 	data := make(map[string]any)
-	source, err := m.getSource(ctx, obj.ValuesFrom.FluxSource.SourceRef)
+	source, err := m.getSource(ctx, valuesSource.SourceRef)
 	if err != nil {
-		return nil, fmt.Errorf("could not get values from source: %w", err)
+		if valuesSource.Optional && apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Info("optional flux source not found", "kind", valuesSource.SourceRef.Kind, "name", valuesSource.SourceRef.Name)
+
+			return nil, nil, "", nil
+		}
+
+		return nil, nil, "", fmt.Errorf("could not get values from source: %w", err)
 	}
 
 	tmpDir, err := os.MkdirTemp("", "mutation-controller-")
 	if err != nil {
-		return nil, fmt.Errorf("could not create temporary directory: %w", err)
+		return nil, nil, "", fmt.Errorf("could not create temporary directory: %w", err)
 	}
 
 	tarSize := tar.UnlimitedUntarSize
@@ -980,56 +1406,79 @@ func (m *MutationReconcileLooper) fromFluxSource(ctx context.Context, obj *v1alp
 	fetcher := fetch.NewArchiveFetcher(retries, tarSize, tarSize, "")
 	artifact := source.GetArtifact()
 	if artifact == nil {
-		return nil, fmt.Errorf("could not get artifact from source: %s", obj.ValuesFrom.FluxSource.SourceRef.Name)
+		return nil, nil, "", fmt.Errorf("could not get artifact from source: %s", valuesSource.SourceRef.Name)
 	}
 	err = fetcher.Fetch(artifact.URL, source.GetArtifact().Digest, tmpDir)
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch values artifact from source: %w", err)
+		return nil, nil, "", fmt.Errorf("could not fetch values artifact from source: %w", err)
 	}
 
-	path, err := securejoin.SecureJoin(tmpDir, obj.ValuesFrom.FluxSource.Path)
+	path, err := securejoin.SecureJoin(tmpDir, valuesSource.Path)
 	if err != nil {
-		return nil, fmt.Errorf("could not construct values file path: %w", err)
+		return nil, nil, "", fmt.Errorf("could not construct values file path: %w", err)
 	}
 
 	dataFile, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("could not read values file: %w", err)
+		return nil, nil, "", fmt.Errorf("could not read values file: %w", err)
+	}
+
+	content, decrypted, err := decryptor.decrypt(dataFile)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("could not decrypt values file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(dataFile, &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal values: %w", err)
+	var secrets secretValues
+	if decrypted {
+		// the decoding error may quote the decrypted content, so it isn't wrapped
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, nil, "", errors.New("failed to unmarshal decrypted values")
+		}
+
+		if secrets, err = collectEncryptedValues(dataFile, content); err != nil {
+			return nil, nil, "", err
+		}
+	} else if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to unmarshal values: %w", err)
 	}
 
 	var found bool
-	if obj.ValuesFrom.FluxSource.SubPath != "" {
-		data, found = extractSubpath(data, obj.ValuesFrom.FluxSource.SubPath)
+	if valuesSource.SubPath != "" {
+		data, found = extractSubpath(data, valuesSource.SubPath)
 		if !found {
-			return nil, errors.New("subPath not found")
+			return nil, nil, "", errors.New("subPath not found")
 		}
 	}
 
-	return data, nil
+	return data, secrets, artifact.Revision, nil
 }
 
+// mutate configures or localizes the source with the config data. The values decrypted from the
+// config data are redacted like the values of Secrets.
 func (m *MutationReconcileLooper) mutate(
 	ctx context.Context,
+	obj v1alpha1.MutationObject,
 	mutationSpec *v1alpha1.MutationSpec,
 	sourceData, configData []byte,
-	namespace, name string,
-) (string, error) {
+	configSecrets secretValues,
+) (string, secretValues, error) {
 	// if values are not nil then this is configuration
-	if mutationSpec.Values != nil || mutationSpec.ValuesFrom != nil {
-		sourceDir, err := m.configure(ctx, sourceData, configData, mutationSpec, namespace, name)
+	if mutationSpec.Values != nil || len(mutationSpec.ValuesFrom) > 0 {
+		sourceDir, secrets, err := m.configure(ctx, obj, sourceData, configData, mutationSpec, configSecrets)
 		if err != nil {
-			return "", fmt.Errorf("failed to configure resource: %w", err)
+			return "", nil, fmt.Errorf("failed to configure resource: %w", err)
 		}
 
-		return sourceDir, nil
+		return sourceDir, secrets, nil
 	}
 
 	// if values are nil then this is localization
-	return m.localize(ctx, mutationSpec, sourceData, configData)
+	sourceDir, err := m.localize(ctx, obj, mutationSpec, sourceData, configData)
+	if err != nil {
+		return "", nil, configSecrets.redactError(err)
+	}
+
+	return sourceDir, configSecrets, nil
 }
 
 func (m *MutationReconcileLooper) mutateConfigRef(
@@ -1037,10 +1486,10 @@ func (m *MutationReconcileLooper) mutateConfigRef(
 	obj v1alpha1.MutationObject,
 	spec *v1alpha1.MutationSpec,
 	sourceData []byte,
-) (string, ocmmetav1.Identity, error) {
+) (string, ocmmetav1.Identity, string, secretValues, error) {
 	configData, err := m.getData(ctx, spec.ConfigRef)
 	if err != nil {
-		return "", ocmmetav1.Identity{}, fmt.Errorf("failed to get data for config ref: %w", err)
+		return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to get data for config ref: %w", err)
 	}
 
 	snapshotID, err := m.getIdentity(ctx, spec.ConfigRef)
@@ -1057,17 +1506,28 @@ func (m *MutationReconcileLooper) mutateConfigRef(
 	// the mutation object.   This the repos for each mutation object will be distinct.
 	snapshotID[v1alpha1.MutationObjectUUIDKey] = string(obj.GetUID())
 	if err != nil {
-		return "", ocmmetav1.Identity{}, fmt.Errorf("failed to get identity for config ref: %w", err)
+		return "", ocmmetav1.Identity{}, "", nil, fmt.Errorf("failed to get identity for config ref: %w", err)
 	}
 
 	obj.GetStatus().LatestConfigVersion = snapshotID[v1alpha1.ComponentVersionKey]
 
-	sourceDir, err := m.mutate(ctx, spec, sourceData, configData, obj.GetNamespace(), obj.GetName())
+	decryptor, err := m.getDecryptor(ctx, obj.GetNamespace(), spec.Decryption)
 	if err != nil {
-		return "", ocmmetav1.Identity{}, err
+		return "", ocmmetav1.Identity{}, "", nil, err
 	}
 
-	return sourceDir, snapshotID, nil
+	// the digest of the config data is computed over the encrypted content
+	configObj, configSecrets, err := decryptConfigData(decryptor, configData)
+	if err != nil {
+		return "", ocmmetav1.Identity{}, "", nil, err
+	}
+
+	sourceDir, secrets, err := m.mutate(ctx, obj, spec, sourceData, configObj, configSecrets)
+	if err != nil {
+		return "", ocmmetav1.Identity{}, "", nil, err
+	}
+
+	return sourceDir, snapshotID, digest.FromBytes(configData).String(), secrets, nil
 }
 
 func (m *MutationReconcileLooper) mutatePatchStrategicMerge(
@@ -1075,28 +1535,79 @@ func (m *MutationReconcileLooper) mutatePatchStrategicMerge(
 	obj v1alpha1.MutationObject,
 	mutationSpec *v1alpha1.MutationSpec,
 	sourceData []byte,
-) (string, ocmmetav1.Identity, error) {
-	// DO NOT Defer remove this, it will be removed once it has been tarred.
+) (_ string, _ ocmmetav1.Identity, _ secretValues, err error) {
+	// It will be removed once it has been tarred. It holds the decrypted patch source, so it's
+	// removed right away if the mutation fails.
 	tmpDir, err := os.MkdirTemp("", "kustomization-")
 	if err != nil {
 		err = fmt.Errorf("tmp dir error: %w", err)
 
-		return "", ocmmetav1.Identity{}, err
+		return "", ocmmetav1.Identity{}, nil, err
 	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpDir)
+		}
+	}()
 
 	// Fetch the data instead.
 	workDir, err := securejoin.SecureJoin(tmpDir, "work")
 	if err != nil {
-		return "", nil, err
+		return "", ocmmetav1.Identity{}, nil, err
+	}
+
+	identity, err := m.fetchPatchSource(ctx, obj, mutationSpec.PatchStrategicMerge.Source.SourceRef, workDir)
+	if err != nil {
+		return "", ocmmetav1.Identity{}, nil, err
+	}
+
+	sourcePath := mutationSpec.PatchStrategicMerge.Source.Path
+	targetPath := mutationSpec.PatchStrategicMerge.Target.Path
+
+	decryptor, err := m.getDecryptor(ctx, obj.GetNamespace(), mutationSpec.Decryption)
+	if err != nil {
+		return "", ocmmetav1.Identity{}, nil, err
+	}
+
+	sourceFile, err := securejoin.SecureJoin(workDir, sourcePath)
+	if err != nil {
+		return "", ocmmetav1.Identity{}, nil, err
+	}
+
+	decrypted, secrets, err := decryptor.decryptFile(sourceFile)
+	if err != nil {
+		return "", ocmmetav1.Identity{}, nil, fmt.Errorf("failed to decrypt patch source: %w", err)
+	}
+
+	if _, err := m.strategicMergePatch(sourceData, tmpDir, workDir, sourcePath, targetPath); err != nil {
+		return "", ocmmetav1.Identity{}, nil, secrets.redactError(err)
+	}
+
+	// the decrypted patch source is part of the result only as far as it has been merged
+	if decrypted && filepath.Clean(sourcePath) != filepath.Clean(targetPath) {
+		if err := os.Remove(sourceFile); err != nil {
+			return "", ocmmetav1.Identity{}, nil, fmt.Errorf("failed to remove decrypted patch source: %w", err)
+		}
 	}
 
+	return workDir, identity, secrets, nil
+}
+
+// fetchPatchSource fetches the content of a GitRepository or of the snapshot of a Resource,
+// Configuration or Localization into workDir and returns the identity describing it.
+func (m *MutationReconcileLooper) fetchPatchSource(
+	ctx context.Context,
+	obj v1alpha1.MutationObject,
+	sourceRef meta.NamespacedObjectKindReference,
+	workDir string,
+) (ocmmetav1.Identity, error) {
 	var identity ocmmetav1.Identity
 
-	switch mutationSpec.PatchStrategicMerge.Source.SourceRef.Kind {
+	switch sourceRef.Kind {
 	case "GitRepository":
-		gitSource, err := m.getSource(ctx, mutationSpec.PatchStrategicMerge.Source.SourceRef)
+		gitSource, err := m.getSource(ctx, sourceRef)
 		if err != nil {
-			return "", ocmmetav1.Identity{}, fmt.Errorf("failed to get patch source: %w", err)
+			return ocmmetav1.Identity{}, fmt.Errorf("failed to get patch source: %w", err)
 		}
 
 		obj.GetStatus().LatestPatchSourceVersion = gitSource.GetArtifact().Revision
@@ -1104,50 +1615,62 @@ func (m *MutationReconcileLooper) mutatePatchStrategicMerge(
 		const retries = 10
 		fetcher := fetch.NewArchiveFetcher(retries, tarSize, tarSize, "")
 		if err := fetcher.Fetch(gitSource.GetArtifact().URL, gitSource.GetArtifact().Digest, workDir); err != nil {
-			return "", nil, err
+			return nil, err
 		}
 		identity = ocmmetav1.Identity{
-			v1alpha1.SourceNameKey:             mutationSpec.PatchStrategicMerge.Source.SourceRef.Name,
-			v1alpha1.SourceNamespaceKey:        mutationSpec.PatchStrategicMerge.Source.SourceRef.Namespace,
+			v1alpha1.SourceNameKey:             sourceRef.Name,
+			v1alpha1.SourceNamespaceKey:        sourceRef.Namespace,
 			v1alpha1.SourceArtifactChecksumKey: gitSource.GetArtifact().Digest,
 		}
 	case v1alpha1.ResourceKind, v1alpha1.ConfigurationKind, v1alpha1.LocalizationKind:
-		data, digest, err := m.fetchDataFromObjectReference(ctx, &v1alpha1.ObjectReference{
-			NamespacedObjectKindReference: mutationSpec.PatchStrategicMerge.Source.SourceRef,
+		data, sourceDigest, err := m.fetchDataFromObjectReference(ctx, &v1alpha1.ObjectReference{
+			NamespacedObjectKindReference: sourceRef,
 		}, false)
 		if err != nil {
-			return "", ocmmetav1.Identity{}, fmt.Errorf("failed to fetch data from source: %w", err)
+			return ocmmetav1.Identity{}, fmt.Errorf("failed to fetch data from source: %w", err)
 		}
 
 		identity = ocmmetav1.Identity{
-			v1alpha1.SourceNameKey:             mutationSpec.PatchStrategicMerge.Source.SourceRef.Name,
-			v1alpha1.SourceNamespaceKey:        mutationSpec.PatchStrategicMerge.Source.SourceRef.Namespace,
-			v1alpha1.SourceArtifactChecksumKey: digest,
+			v1alpha1.SourceNameKey:             sourceRef.Name,
+			v1alpha1.SourceNamespaceKey:        sourceRef.Namespace,
+			v1alpha1.SourceArtifactChecksumKey: sourceDigest,
 		}
 
 		if _, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
 			if err := tar.Untar(bytes.NewReader(data), workDir); err != nil {
-				return "", ocmmetav1.Identity{}, fmt.Errorf("failed to untar data from source: %w", err)
+				return ocmmetav1.Identity{}, fmt.Errorf("failed to untar data from source: %w", err)
 			}
 		} else {
 			const perm = 0o755
 			if err := os.MkdirAll(workDir, perm); err != nil {
-				return "", ocmmetav1.Identity{}, fmt.Errorf("failed to create work dir: %w", err)
+				return ocmmetav1.Identity{}, fmt.Errorf("failed to create work dir: %w", err)
 			}
 
 			if err := untar.Untar(bytes.NewReader(data), workDir); err != nil {
-				return "", ocmmetav1.Identity{}, fmt.Errorf("failed to untar data from source without gzip: %w", err)
+				return ocmmetav1.Identity{}, fmt.Errorf("failed to untar data from source without gzip: %w", err)
 			}
 		}
 	}
 
-	sourcePath := mutationSpec.PatchStrategicMerge.Source.Path
-	targetPath := mutationSpec.PatchStrategicMerge.Target.Path
-	if _, err := m.strategicMergePatch(sourceData, tmpDir, workDir, sourcePath, targetPath); err != nil {
-		return "", ocmmetav1.Identity{}, err
+	return identity, nil
+}
+
+// mergeValues deep merges the values of src into dst. Maps are merged recursively, any other value
+// of src replaces the value of dst.
+func mergeValues(dst, src map[string]any) map[string]any {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			dst[key] = mergeValues(dstMap, srcMap)
+
+			continue
+		}
+
+		dst[key] = value
 	}
 
-	return workDir, identity, nil
+	return dst
 }
 
 // Recursive function to extract the subpath from the data map.
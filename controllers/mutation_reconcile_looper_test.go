@@ -1,24 +1,58 @@
 package controllers
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
+	"github.com/fluxcd/pkg/apis/kustomize"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
 
+	ocmcore "ocm.software/ocm/api/ocm"
 	v1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
 	"ocm.software/ocm/api/ocm/compdesc/versions/ocm.software/v3alpha1"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/git"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/helm"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/localblob"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/ociartifact"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/s3"
+	"ocm.software/ocm/api/ocm/extensions/accessmethods/wget"
 	"ocm.software/ocm/api/ocm/ocmutils/localize"
 	ocmruntime "ocm.software/ocm/api/utils/runtime"
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	cachefakes "github.com/open-component-model/ocm-controller/pkg/cache/fakes"
 	"github.com/open-component-model/ocm-controller/pkg/component"
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+	"github.com/open-component-model/ocm-controller/pkg/ocm/fakes"
 	"github.com/open-component-model/ocm-controller/pkg/snapshot"
 )
 
@@ -61,9 +95,7 @@ func TestGenerateSubstitutionsUseDefaults(t *testing.T) {
 	vls := `dmi:
   some_aws_val: blah`
 
-	schm := ""
-
-	oSbs, err := m.generateSubstitutions(iSbs, []byte(dflts), []byte(vls), []byte(schm))
+	oSbs, err := m.generateSubstitutions(iSbs, []byte(dflts), []byte(vls))
 	assert.NoError(t, err)
 	assert.Equal(t, len(iSbs), len(oSbs))
 	var expected json.RawMessage
@@ -194,6 +226,85 @@ func TestPopulateReferences(t *testing.T) {
 	}
 }
 
+func TestRenderConfiguration(t *testing.T) {
+	ctx := context.Background()
+
+	cd := (&componentGenerator{
+		name:    "frontend",
+		version: "v1.0.0",
+		resources: []resource{{
+			name:    "web-server",
+			version: "1.23.3-alpine",
+			image:   "nginx:1.23-3-alpine",
+		}},
+	}).build(true)
+	require.NotNil(t, cd)
+
+	cv := &v1alpha1.ComponentVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "frontend",
+			Namespace: cd.Namespace,
+		},
+		Status: v1alpha1.ComponentVersionStatus{
+			ComponentDescriptor: v1alpha1.Reference{
+				Name:    "frontend",
+				Version: "v1.0.0",
+				ComponentDescriptorRef: meta.NamespacedObjectReference{
+					Name:      cd.Name,
+					Namespace: cd.Namespace,
+				},
+			},
+		},
+	}
+
+	m := &MutationReconcileLooper{
+		Scheme: env.scheme,
+		Client: env.FakeKubeClient(WithObjects(cd, cv)),
+	}
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "main.jsonnet"), []byte(`{
+  'generated/values.yaml': {
+    image: std.extVar('component').resources[0].name,
+    replicas: std.extVar('values').replicas,
+    color: std.extVar('values').color,
+  },
+}
+`), 0o600))
+
+	configData := `apiVersion: config.ocm.software/v1alpha1
+kind: ConfigData
+metadata:
+  name: ocm-config
+configuration:
+  defaults:
+    replicas: 1
+    color: red
+  rules: []
+  render:
+    engine: jsonnet
+    path: main.jsonnet
+`
+
+	spec := &v1alpha1.MutationSpec{
+		ConfigRef: &v1alpha1.ObjectReference{
+			NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+				Kind:      v1alpha1.ComponentVersionKind,
+				Name:      cv.Name,
+				Namespace: cv.Namespace,
+			},
+		},
+	}
+
+	values := &apiextensionsv1.JSON{Raw: []byte(`{"replicas":3}`)}
+
+	require.NoError(t, m.renderConfiguration(ctx, []byte(configData), values, spec, sourceDir))
+
+	content, err := os.ReadFile(filepath.Join(sourceDir, "generated", "values.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "color: red\nimage: web-server\nreplicas: 3\n", string(content))
+}
+
 func (c *componentGenerator) build(isRoot bool) *v1alpha1.ComponentDescriptor {
 	resources := make([]v3alpha1.Resource, len(c.resources))
 	for i, r := range c.resources {
@@ -259,3 +370,2827 @@ func makeReference(name, version, component string) v3alpha1.Reference {
 		ComponentName: component,
 	}
 }
+
+func TestSnapshotLocation(t *testing.T) {
+	objects := func(namespace string) []client.Object {
+		return []client.Object{
+			&v1alpha1.Resource{
+				ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: namespace},
+				Status:     v1alpha1.ResourceStatus{SnapshotName: "manifests-snapshot"},
+			},
+			&v1alpha1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{Name: "manifests-snapshot", Namespace: namespace},
+				Status: v1alpha1.SnapshotStatus{
+					RepositoryURL:     "https://registry.ocm-system.svc.cluster.local:5000/sha-" + namespace,
+					LastReconciledTag: "v0.0.1",
+				},
+			},
+		}
+	}
+
+	m := &MutationReconcileLooper{
+		Client: env.FakeKubeClient(WithObjects(append(objects("default"), objects("other")...)...)),
+	}
+
+	location, err := m.snapshotLocation(context.Background(), &configdata.SnapshotItem{Name: "manifests"}, "default")
+	require.NoError(t, err)
+	assert.Equal(t, "https://registry.ocm-system.svc.cluster.local:5000/sha-default", location.URL)
+	assert.Equal(t, "v0.0.1", location.Ref)
+
+	_, err = m.snapshotLocation(context.Background(), &configdata.SnapshotItem{Name: "manifests", Namespace: "default"}, "default")
+	require.NoError(t, err)
+
+	_, err = m.snapshotLocation(context.Background(), &configdata.SnapshotItem{Name: "manifests", Namespace: "other"}, "default")
+	require.EqualError(t, err, "resource other/manifests is not in namespace default of the localized object")
+}
+
+func TestLocalizeImages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: podinfo
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox:1.36
+      containers:
+        - name: podinfo
+          image: ghcr.io/stefanprodan/podinfo:6.2.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: podinfo
+spec:
+  selector:
+    app: podinfo
+`,
+		"cronjob.yaml": `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: nginx
+`,
+		"release.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: redis
+spec:
+  values:
+    image: redis:7.2
+`,
+		"templates/deployment.yaml": `image: {{ .Values.image }}
+`,
+		"pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: fork
+spec:
+  containers:
+    - name: podinfo
+      image: quay.io/stefanprodan/podinfo:6.2.0
+`,
+	}
+	for file, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o600))
+	}
+
+	parse := func(ref string) name.Reference {
+		r, err := name.ParseReference(ref)
+		require.NoError(t, err)
+
+		return r
+	}
+
+	resources := []imageResource{
+		{
+			component: "github.com/open-component-model/podinfo",
+			resource:  "podinfo-image",
+			reference: parse("registry.local/ocm/stefanprodan/podinfo:6.3.5"),
+			label:     "ghcr.io/stefanprodan/podinfo",
+		},
+		{
+			component: "github.com/open-component-model/podinfo",
+			resource:  "web-server",
+			reference: parse("registry.local/mirror/web:1.25"),
+			label:     "docker.io/library/nginx",
+		},
+		{
+			component: "github.com/open-component-model/podinfo",
+			resource:  "transferred-podinfo",
+			reference: parse("registry.local/stefanprodan/podinfo:6.3.5"),
+		},
+		{
+			component: "github.com/open-component-model/redis",
+			resource:  "mirrored-redis",
+			reference: parse("registry.local/library/redis:7.2.4"),
+		},
+		{
+			component: "github.com/open-component-model/redis",
+			resource:  "redis",
+			reference: parse("docker.io/library/redis:7.2.4"),
+		},
+		{
+			component: "github.com/open-component-model/redis",
+			resource:  "transferred-redis",
+			reference: parse("registry.local/ocm/redis:7.2.4"),
+		},
+	}
+
+	localized, unmatched, err := localizeImages(dir, []string{"$.spec.values.image"}, matchImage(resources), true)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []v1alpha1.LocalizedImage{
+		{
+			File:      "deployment.yaml",
+			Object:    "Deployment/podinfo",
+			Path:      "$.spec.template.spec.containers[*].image",
+			Image:     "ghcr.io/stefanprodan/podinfo:6.2.0",
+			Localized: "registry.local/ocm/stefanprodan/podinfo:6.3.5",
+			Component: "github.com/open-component-model/podinfo",
+			Resource:  "podinfo-image",
+		},
+		{
+			File:      "cronjob.yaml",
+			Object:    "CronJob/cleanup",
+			Path:      "$.spec.jobTemplate.spec.template.spec.containers[*].image",
+			Image:     "nginx",
+			Localized: "registry.local/mirror/web:1.25",
+			Component: "github.com/open-component-model/podinfo",
+			Resource:  "web-server",
+		},
+		{
+			File:      "release.yaml",
+			Object:    "HelmRelease/redis",
+			Path:      "$.spec.values.image",
+			Image:     "redis:7.2",
+			Localized: "docker.io/library/redis:7.2.4",
+			Component: "github.com/open-component-model/redis",
+			Resource:  "redis",
+		},
+		{
+			File:      "pod.yaml",
+			Object:    "Pod/fork",
+			Path:      "$.spec.containers[*].image",
+			Image:     "quay.io/stefanprodan/podinfo:6.2.0",
+			Localized: "registry.local/stefanprodan/podinfo:6.3.5",
+			Component: "github.com/open-component-model/podinfo",
+			Resource:  "transferred-podinfo",
+		},
+	}, localized)
+
+	assert.Equal(t, []v1alpha1.LocalizedImage{
+		{
+			File:   "deployment.yaml",
+			Object: "Deployment/podinfo",
+			Path:   "$.spec.template.spec.initContainers[*].image",
+			Image:  "busybox:1.36",
+		},
+	}, unmatched)
+
+	content, err := os.ReadFile(filepath.Join(dir, "deployment.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "image: registry.local/ocm/stefanprodan/podinfo:6.3.5")
+	assert.Contains(t, string(content), "image: busybox:1.36")
+	assert.Contains(t, string(content), "kind: Service")
+
+	content, err = os.ReadFile(filepath.Join(dir, "templates", "deployment.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, files["templates/deployment.yaml"], string(content))
+}
+
+func TestDigestResolver(t *testing.T) {
+	const (
+		pinned   = "sha256:0b5e2d81e5e8e1d8e0a0c1e6a3b9b1f3c2d0f1a4e5b6c7d8e9f0a1b2c3d4e5f6"
+		recorded = "sha256:1c6f3e92f6f9f2e9f1b1d2f7b4cac2a4d3e1a2b5f6c7d8e9fa0b1c2d3e4f5a6b"
+		resolved = "sha256:2d7a4fa3a7a0a3fa02c2e3a8c5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c"
+	)
+
+	fakeOcm := &fakes.MockFetcher{}
+	fakeOcm.ResolveImageDigestReturns(resolved, nil)
+
+	resolver := newDigestResolver(context.Background(), nil, fakeOcm, []v1alpha1.ResolvedDigest{
+		{
+			Reference: "ghcr.io/open-component-model/podinfo:6.3.5",
+			Digest:    recorded,
+		},
+	})
+
+	ref, err := name.ParseReference("ghcr.io/open-component-model/podinfo@" + pinned)
+	require.NoError(t, err)
+	dgst, err := resolver.resolve(ref, nil)
+	require.NoError(t, err)
+	assert.Equal(t, pinned, dgst)
+
+	ref, err = name.ParseReference("ghcr.io/open-component-model/redis:7.2")
+	require.NoError(t, err)
+	dgst, err = resolver.resolve(ref, &v1.DigestSpec{
+		HashAlgorithm:          "SHA-256",
+		NormalisationAlgorithm: "ociArtifactDigest/v1",
+		Value:                  pinned[len("sha256:"):],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pinned, dgst)
+
+	ref, err = name.ParseReference("ghcr.io/open-component-model/podinfo:6.3.5")
+	require.NoError(t, err)
+	dgst, err = resolver.resolve(ref, nil)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, dgst)
+	assert.True(t, fakeOcm.ResolveImageDigestWasNotCalled())
+
+	ref, err = name.ParseReference("ghcr.io/open-component-model/nginx:1.25")
+	require.NoError(t, err)
+	for range 2 {
+		dgst, err = resolver.resolve(ref, nil)
+		require.NoError(t, err)
+		assert.Equal(t, resolved, dgst)
+	}
+	assert.Equal(t, []any{ref}, fakeOcm.ResolveImageDigestCallingArgumentsOnCall(0))
+
+	assert.Equal(t, []v1alpha1.ResolvedDigest{
+		{
+			Reference: "ghcr.io/open-component-model/podinfo:6.3.5",
+			Digest:    recorded,
+		},
+		{
+			Reference: "ghcr.io/open-component-model/nginx:1.25",
+			Digest:    resolved,
+		},
+	}, resolver.resolved)
+}
+
+func TestAccessLocation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     ocmcore.AccessSpec
+		expected *resourceLocation
+		err      string
+	}{
+		{
+			name: "helm repository",
+			spec: &helm.AccessSpec{
+				HelmRepository: "https://stefanprodan.github.io/podinfo",
+				HelmChart:      "podinfo:6.3.5",
+			},
+			expected: &resourceLocation{
+				URL:  "https://stefanprodan.github.io/podinfo",
+				Host: "stefanprodan.github.io",
+				Path: "podinfo",
+				Ref:  "6.3.5",
+			},
+		},
+		{
+			name: "http blob",
+			spec: &wget.AccessSpec{
+				URL: "https://downloads.example.com/tools/cli-v1.2.0.tar.gz",
+			},
+			expected: &resourceLocation{
+				URL:  "https://downloads.example.com/tools/cli-v1.2.0.tar.gz",
+				Host: "downloads.example.com",
+				Path: "tools/cli-v1.2.0.tar.gz",
+			},
+		},
+		{
+			name: "s3 blob",
+			spec: &s3.AccessSpec{
+				Bucket:  "binaries",
+				Key:     "cli/v1.2.0/cli.tar.gz",
+				Version: "3HL4kqtJlcpXroDTDmJ",
+			},
+			expected: &resourceLocation{
+				URL:  "s3://binaries/cli/v1.2.0/cli.tar.gz",
+				Host: "binaries",
+				Path: "cli/v1.2.0/cli.tar.gz",
+				Ref:  "3HL4kqtJlcpXroDTDmJ",
+			},
+		},
+		{
+			name: "git repository",
+			spec: &git.AccessSpec{
+				RepoURL: "https://github.com/open-component-model/podinfo.git",
+				Ref:     "refs/heads/main",
+			},
+			expected: &resourceLocation{
+				URL:  "https://github.com/open-component-model/podinfo.git",
+				Host: "github.com",
+				Path: "open-component-model/podinfo.git",
+				Ref:  "refs/heads/main",
+			},
+		},
+		{
+			name: "local blob without global access",
+			spec: &localblob.AccessSpec{},
+			err:  "cannot determine location of local blob without global access",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := accessLocation(nil, tt.spec)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, location)
+		})
+	}
+
+	location, err := accessLocation(nil, &ociartifact.AccessSpec{
+		ImageReference: "ghcr.io/stefanprodan/podinfo:6.3.5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "oci://ghcr.io/stefanprodan/podinfo", location.URL)
+	assert.Equal(t, "ghcr.io", location.Host)
+	assert.Equal(t, "stefanprodan/podinfo", location.Path)
+	assert.Equal(t, "6.3.5", location.Ref)
+	assert.Equal(t, "ghcr.io/stefanprodan/podinfo:6.3.5", location.Reference.Name())
+}
+
+func TestLocalizationPlatform(t *testing.T) {
+	spec := &v1alpha1.MutationSpec{
+		Platform: &v1alpha1.Platform{OS: "linux", Architecture: "arm64"},
+	}
+
+	platform := localizationPlatform(configdata.LocalizationRule{
+		Platform: &configdata.Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+	}, spec, "helmChart")
+	require.NotNil(t, platform)
+	assert.Equal(t, "linux/arm/v7", platform.String())
+
+	platform = localizationPlatform(configdata.LocalizationRule{}, spec, ociImageResourceType)
+	require.NotNil(t, platform)
+	assert.Equal(t, "linux/arm64", platform.String())
+
+	assert.Nil(t, localizationPlatform(configdata.LocalizationRule{}, spec, "helmChart"))
+	assert.Nil(t, localizationPlatform(configdata.LocalizationRule{}, &v1alpha1.MutationSpec{}, ociImageResourceType))
+
+	fakeOcm := &fakes.MockFetcher{}
+	fakeOcm.ResolvePlatformDigestReturns("sha256:3e8b5fb4b8b1b4b0b4c2d3e8a5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c", nil)
+	resolver := newDigestResolver(context.Background(), nil, fakeOcm, nil)
+
+	ref, err := name.ParseReference("ghcr.io/stefanprodan/podinfo:6.3.5")
+	require.NoError(t, err)
+	for range 2 {
+		dgst, err := resolver.resolvePlatform(ref, *platform)
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:3e8b5fb4b8b1b4b0b4c2d3e8a5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c", dgst)
+	}
+	assert.Equal(t, []any{ref, *platform}, fakeOcm.ResolvePlatformDigestCallingArgumentsOnCall(0))
+	assert.Empty(t, resolver.resolved)
+}
+
+func TestRegistryRewriter(t *testing.T) {
+	localization := &v1alpha1.Localization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "podinfo",
+			Namespace: "default",
+		},
+	}
+
+	fakeClient := env.FakeKubeClient(WithObjects(
+		&v1alpha1.RegistryRewritePolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "harbor",
+				Namespace: "default",
+			},
+			Spec: v1alpha1.RegistryRewritePolicySpec{
+				Rules: []v1alpha1.RegistryRewriteRule{
+					{
+						Prefix:  "ghcr.io/",
+						Replace: "harbor.internal/ghcr/",
+					},
+				},
+			},
+		},
+		&v1alpha1.RegistryRewritePolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other",
+				Namespace: "other",
+			},
+			Spec: v1alpha1.RegistryRewritePolicySpec{
+				Rules: []v1alpha1.RegistryRewriteRule{
+					{
+						Prefix:  "quay.io/",
+						Replace: "other.internal/quay/",
+					},
+				},
+			},
+		},
+		&v1alpha1.ClusterRegistryRewritePolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "mirror",
+			},
+			Spec: v1alpha1.RegistryRewritePolicySpec{
+				Rules: []v1alpha1.RegistryRewriteRule{
+					{
+						Prefix:  "ghcr.io/stefanprodan/",
+						Replace: "mirror.internal/stefanprodan/",
+					},
+					{
+						Regex:   `^(quay\.io|docker\.io)/(.+)$`,
+						Replace: "mirror.internal/$1/$2",
+					},
+				},
+			},
+		},
+	))
+
+	m := &MutationReconcileLooper{
+		Client: fakeClient,
+	}
+
+	rewriter, err := m.registryRewriter(context.Background(), localization)
+	require.NoError(t, err)
+
+	for image, expected := range map[string]string{
+		// the cluster policies take precedence over the policies of the namespace
+		"ghcr.io/stefanprodan/podinfo:6.3.5":  "mirror.internal/stefanprodan/podinfo:6.3.5",
+		"ghcr.io/fluxcd/flux-cli:v2.2.0":      "harbor.internal/ghcr/fluxcd/flux-cli:v2.2.0",
+		"quay.io/jetstack/cert-manager:v1.13": "mirror.internal/quay.io/jetstack/cert-manager:v1.13",
+		"nginx:1.25":                          "mirror.internal/docker.io/library/nginx:1.25",
+	} {
+		ref, err := name.ParseReference(image)
+		require.NoError(t, err)
+
+		rewritten, err := rewriter.rewrite(ref)
+		require.NoError(t, err)
+		assert.Equal(t, expected, rewritten.Name())
+	}
+
+	ref, err := name.ParseReference("ghcr.io/stefanprodan/podinfo:6.3.5")
+	require.NoError(t, err)
+	_, err = rewriter.rewrite(ref)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []v1alpha1.RewrittenReference{
+		{
+			Reference: "ghcr.io/stefanprodan/podinfo:6.3.5",
+			Rewritten: "mirror.internal/stefanprodan/podinfo:6.3.5",
+			Policy:    "ClusterRegistryRewritePolicy/mirror",
+		},
+		{
+			Reference: "ghcr.io/fluxcd/flux-cli:v2.2.0",
+			Rewritten: "harbor.internal/ghcr/fluxcd/flux-cli:v2.2.0",
+			Policy:    "RegistryRewritePolicy/default/harbor",
+		},
+		{
+			Reference: "quay.io/jetstack/cert-manager:v1.13",
+			Rewritten: "mirror.internal/quay.io/jetstack/cert-manager:v1.13",
+			Policy:    "ClusterRegistryRewritePolicy/mirror",
+		},
+		{
+			Reference: "docker.io/library/nginx:1.25",
+			Rewritten: "mirror.internal/docker.io/library/nginx:1.25",
+			Policy:    "ClusterRegistryRewritePolicy/mirror",
+		},
+	}, localization.Status.RewrittenReferences)
+
+	invalid := env.FakeKubeClient(WithObjects(&v1alpha1.ClusterRegistryRewritePolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "invalid",
+		},
+		Spec: v1alpha1.RegistryRewritePolicySpec{
+			Rules: []v1alpha1.RegistryRewriteRule{
+				{
+					Prefix:  "ghcr.io/",
+					Regex:   "^ghcr.io/",
+					Replace: "harbor.internal/",
+				},
+			},
+		},
+	}))
+
+	_, err = (&MutationReconcileLooper{Client: invalid}).registryRewriter(context.Background(), localization)
+	assert.EqualError(t, err, "rule 0 of ClusterRegistryRewritePolicy/invalid must set either prefix or regex")
+}
+
+func TestKustomizeImages(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: podinfo
+spec:
+  template:
+    spec:
+      containers:
+        - name: podinfo
+          image: ghcr.io/stefanprodan/podinfo:6.2.0
+        - name: redis
+          image: redis:7.2
+`
+
+	rules := substitutions{
+		{
+			Substitution: localize.Substitution{
+				FilePath: "deployment.yaml",
+				ValueMapping: localize.ValueMapping{
+					Name:      "image",
+					ValuePath: "spec.template.spec.containers[0].image",
+					Value:     []byte(`"registry.local/stefanprodan/podinfo:6.3.5"`),
+				},
+			},
+			rule:  "localization rule 0",
+			image: "registry.local/stefanprodan/podinfo:6.3.5",
+		},
+		{
+			Substitution: localize.Substitution{
+				FilePath: "deployment.yaml",
+				ValueMapping: localize.ValueMapping{
+					Name:      "image",
+					ValuePath: "spec.template.spec.containers[1].image",
+					Value:     []byte(`"index.docker.io/library/redis@sha256:2d7a4fa3a7a0a3fa02c2e3a8c5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c"`),
+				},
+			},
+			rule:  "localization rule 1",
+			image: "index.docker.io/library/redis@sha256:2d7a4fa3a7a0a3fa02c2e3a8c5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c",
+		},
+	}
+
+	t.Run("merges into the existing kustomization", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(deployment), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - deployment.yaml
+images:
+  - name: busybox
+    newTag: "1.36"
+  - name: redis
+    newTag: "7.0"
+`), 0o600))
+
+		require.NoError(t, kustomizeImages(dir, rules))
+
+		content, err := os.ReadFile(filepath.Join(dir, "deployment.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, deployment, string(content))
+
+		kus := &kustypes.Kustomization{}
+		content, err = os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+		require.NoError(t, err)
+		require.NoError(t, yaml.Unmarshal(content, kus))
+
+		assert.Equal(t, []string{"deployment.yaml"}, kus.Resources)
+		assert.Equal(t, []kustypes.Image{
+			{
+				Name:   "busybox",
+				NewTag: "1.36",
+			},
+			{
+				Name:    "redis",
+				NewName: "index.docker.io/library/redis",
+				Digest:  "sha256:2d7a4fa3a7a0a3fa02c2e3a8c5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c",
+			},
+			{
+				Name:    "ghcr.io/stefanprodan/podinfo",
+				NewName: "registry.local/stefanprodan/podinfo",
+				NewTag:  "6.3.5",
+			},
+		}, kus.Images)
+	})
+
+	t.Run("generates a kustomization", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(deployment), 0o600))
+
+		require.NoError(t, kustomizeImages(dir, rules[:1]))
+
+		kus := &kustypes.Kustomization{}
+		content, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+		require.NoError(t, err)
+		require.NoError(t, yaml.Unmarshal(content, kus))
+
+		assert.Equal(t, []string{"deployment.yaml"}, kus.Resources)
+		assert.Equal(t, []kustypes.Image{
+			{
+				Name:    "ghcr.io/stefanprodan/podinfo",
+				NewName: "registry.local/stefanprodan/podinfo",
+				NewTag:  "6.3.5",
+			},
+		}, kus.Images)
+	})
+
+	t.Run("derives the images of registry, repository, tag and digest rules", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte(`podinfo:
+  registry: ghcr.io
+  repository: stefanprodan/podinfo
+  tag: 6.2.0
+redis:
+  image: redis
+  digest: ""
+`), 0o600))
+
+		rule := func(index int, name, path, value, image string) substitution {
+			return substitution{
+				Substitution: localize.Substitution{
+					FilePath: "values.yaml",
+					ValueMapping: localize.ValueMapping{
+						Name:      name,
+						ValuePath: path,
+						Value:     []byte(value),
+					},
+				},
+				rule:  fmt.Sprintf("localization rule %d", index),
+				image: image,
+			}
+		}
+
+		const digest = "sha256:2d7a4fa3a7a0a3fa02c2e3a8c5dbd3b5e4f2b3c6a7d8e9fa0b1c2d3e4f5a6b7c"
+
+		require.NoError(t, kustomizeImages(dir, substitutions{
+			rule(0, "registry", "podinfo.registry", `"registry.local"`, "registry.local/stefanprodan/podinfo:6.3.5"),
+			rule(0, "repository", "podinfo.repository", `"stefanprodan/podinfo"`, "registry.local/stefanprodan/podinfo:6.3.5"),
+			rule(0, "tag", "podinfo.tag", `"6.3.5"`, "registry.local/stefanprodan/podinfo:6.3.5"),
+			rule(1, "fullyQualifiedRepository", "redis.image", `"registry.local/redis"`, "registry.local/redis:7.2.4"),
+			rule(1, "digest", "redis.digest", `"`+digest+`"`, "registry.local/redis:7.2.4"),
+		}))
+
+		kus := &kustypes.Kustomization{}
+		content, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+		require.NoError(t, err)
+		require.NoError(t, yaml.Unmarshal(content, kus))
+
+		assert.Equal(t, []kustypes.Image{
+			{
+				Name:    "ghcr.io/stefanprodan/podinfo",
+				NewName: "registry.local/stefanprodan/podinfo",
+				NewTag:  "6.3.5",
+			},
+			{
+				Name:    "redis",
+				NewName: "registry.local/redis",
+				NewTag:  "7.2.4",
+				Digest:  digest,
+			},
+		}, kus.Images)
+	})
+
+	t.Run("rejects rules without the image name", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(deployment), 0o600))
+
+		err := kustomizeImages(dir, substitutions{
+			{
+				Substitution: localize.Substitution{
+					FilePath: "deployment.yaml",
+					ValueMapping: localize.ValueMapping{
+						Name:      "tag",
+						ValuePath: "spec.template.spec.containers[0].image",
+						Value:     []byte(`"6.3.5"`),
+					},
+				},
+				rule:  "localization rule 0",
+				image: "registry.local/stefanprodan/podinfo:6.3.5",
+			},
+		})
+		assert.EqualError(t, err, "localization rule 0 doesn't localize the image name in deployment.yaml, which is required by the KustomizeImages output")
+	})
+
+	t.Run("rejects other substitutions", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(deployment), 0o600))
+
+		err := kustomizeImages(dir, substitutions{
+			{
+				Substitution: localize.Substitution{
+					FilePath: "deployment.yaml",
+					ValueMapping: localize.ValueMapping{
+						Name:      "url",
+						ValuePath: "metadata.annotations.url",
+						Value:     []byte(`"https://registry.local/charts"`),
+					},
+				},
+				rule: "localization rule 0",
+			},
+		})
+		assert.EqualError(t, err, "the url substitution of deployment.yaml can't be expressed as a kustomize image")
+	})
+}
+
+func TestKustomizeLocalizedImages(t *testing.T) {
+	dir := t.TempDir()
+
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: podinfo
+spec:
+  template:
+    spec:
+      containers:
+        - name: podinfo
+          image: ghcr.io/stefanprodan/podinfo:6.2.0
+`
+	release := `apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: redis
+spec:
+  values:
+    image: redis:7.2
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(deployment), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release.yaml"), []byte(release), 0o600))
+
+	parse := func(ref string) name.Reference {
+		r, err := name.ParseReference(ref)
+		require.NoError(t, err)
+
+		return r
+	}
+
+	resources := []imageResource{
+		{
+			component: "github.com/open-component-model/podinfo",
+			resource:  "podinfo-image",
+			reference: parse("registry.local/ocm/stefanprodan/podinfo:6.3.5"),
+			label:     "ghcr.io/stefanprodan/podinfo",
+		},
+		{
+			component: "github.com/open-component-model/podinfo",
+			resource:  "redis",
+			reference: parse("docker.io/library/redis:7.2.4"),
+		},
+	}
+
+	localized, _, err := localizeImages(dir, []string{"$.spec.values.image"}, matchImage(resources), false)
+	require.NoError(t, err)
+	require.Len(t, localized, 2)
+
+	require.NoError(t, kustomizeLocalizedImages(dir, localized))
+
+	// the container images are localized by kustomize, the images of other paths in place
+	content, err := os.ReadFile(filepath.Join(dir, "deployment.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, deployment, string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "release.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "image: docker.io/library/redis:7.2.4")
+
+	kus := &kustypes.Kustomization{}
+	content, err = os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, kus))
+
+	assert.ElementsMatch(t, []string{"deployment.yaml", "release.yaml"}, kus.Resources)
+	assert.Equal(t, []kustypes.Image{
+		{
+			Name:    "ghcr.io/stefanprodan/podinfo",
+			NewName: "registry.local/ocm/stefanprodan/podinfo",
+			NewTag:  "6.3.5",
+		},
+	}, kus.Images)
+}
+
+func TestGetValuesFromSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "values",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"values.yaml": []byte("database:\n  password: hunter2\n  port: 5432\nreplicas: 3\n"),
+			"invalid":     []byte("password: [hunter2"),
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		source      v1alpha1.SecretSource
+		values      string
+		secrets     secretValues
+		expectError string
+	}{
+		{
+			name: "values of the key",
+			source: v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: "values"},
+				Key:       "values.yaml",
+			},
+			values:  `{"database":{"password":"hunter2","port":5432},"replicas":3}`,
+			secrets: secretValues{"hunter2", "5432", "3"},
+		},
+		{
+			name: "values of the sub path",
+			source: v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: "values"},
+				Key:       "values.yaml",
+				SubPath:   "database",
+			},
+			values:  `{"password":"hunter2","port":5432}`,
+			secrets: secretValues{"hunter2", "5432", "3"},
+		},
+		{
+			name: "optional secret not found",
+			source: v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: "missing"},
+				Key:       "values.yaml",
+				Optional:  true,
+			},
+			values: `{}`,
+		},
+		{
+			name: "secret not found",
+			source: v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: "missing"},
+				Key:       "values.yaml",
+			},
+			expectError: "failed to get secret",
+		},
+		{
+			name: "key not found",
+			source: v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: "values"},
+				Key:       "other.yaml",
+			},
+			expectError: "key other.yaml not found in secret values",
+		},
+		{
+			name: "invalid content isn't revealed",
+			source: v1alpha1.SecretSource{
+				SourceRef: meta.LocalObjectReference{Name: "values"},
+				Key:       "invalid",
+			},
+			expectError: "failed to get values from secret source: failed to unmarshal values of key invalid in secret values",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := env.FakeKubeClient(WithObjects(secret.DeepCopy()))
+			m := &MutationReconcileLooper{
+				Client:    client,
+				APIReader: client,
+			}
+
+			configuration := &v1alpha1.Configuration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.MutationSpec{
+					ValuesFrom: []v1alpha1.ValuesSource{
+						{
+							SecretSource: &tc.source,
+						},
+					},
+				},
+			}
+
+			values, secrets, err := m.getValues(context.Background(), configuration, &configuration.Spec)
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+				assert.NotContains(t, err.Error(), "hunter2")
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.values, string(values.Raw))
+			assert.Equal(t, tc.secrets, secrets)
+		})
+	}
+}
+
+func TestSecretValuesRedactError(t *testing.T) {
+	secrets := collectSecretValues(map[string]any{
+		"user":     "admin",
+		"password": "admin-password",
+		"tokens":   []any{"t0ken"},
+		"enabled":  true,
+	})
+	assert.Equal(t, secretValues{"admin-password", "admin", "t0ken", "true"}, secrets)
+
+	err := secrets.redactError(errors.New(`validation failed: "admin-password" does not match "^admin$", token t0ken is invalid`))
+	assert.EqualError(t, err, `validation failed: "**REDACTED**" does not match "^**REDACTED**$", token **REDACTED** is invalid`)
+
+	original := errors.New("no values found")
+	assert.Same(t, original, secrets.redactError(original))
+	assert.NoError(t, secrets.redactError(nil))
+
+	t.Log("verifying that short values are collected")
+	secrets = collectSecretValues(map[string]any{
+		"replicas": float64(1),
+		"port":     float64(5432),
+		"name":     "db",
+		"empty":    "",
+	})
+	assert.Equal(t, secretValues{"5432", "db", "1"}, secrets)
+
+	t.Log("verifying that values are redacted where they form a token")
+	assert.Equal(t, "port **REDACTED** must be set, got **REDACTED**", secrets.redact("port 5432 must be set, got db"))
+	assert.Equal(t, "user=**REDACTED**", secretValues{"p@ss!"}.redact("user=p@ss!"))
+
+	t.Log("verifying that the lines revealing values within words or numbers are redacted as a whole")
+	assert.Equal(t, "**REDACTED**", secretValues{"admin"}.redact("administrator admin:admin"))
+	assert.Equal(t, "**REDACTED**\nvalidation failed\n", secrets.redact("port 15432 must be >= 1\nvalidation failed\n"))
+	assert.Equal(t, "**REDACTED**", secretValues{"7"}.redact("pin 1337"))
+
+	err = secretValues{"42"}.redactError(errors.New("expected 4 digits, got 420"))
+	assert.EqualError(t, err, "**REDACTED**")
+
+	t.Log("verifying that a value spanning lines which isn't a token redacts the whole message")
+	assert.Equal(t, "**REDACTED**", secretValues{"a\nb"}.redact("xa\nb"))
+}
+
+func TestCollectEncryptedValues(t *testing.T) {
+	encrypted := []byte(`database:
+  host: db.internal
+  password: ENC[AES256_GCM,data:J8WJzpXqGg==,iv:a8Hg,tag:2jk9,type:str]
+  port: ENC[AES256_GCM,data:TQ==,iv:U8hQ,tag:N6Rd,type:int]
+tokens:
+  - ENC[AES256_GCM,data:hLU19ehm,iv:+UWW,tag:Z+xV,type:str]
+  - public-token
+sops:
+  mac: ENC[AES256_GCM,data:inHg,iv:bomk,tag:Bssh,type:str]
+  version: 3.13.3
+`)
+	decrypted := []byte(`database:
+  host: db.internal
+  password: hunter2
+  port: 5432
+tokens:
+  - t0ken-1
+  - public-token
+`)
+
+	secrets, err := collectEncryptedValues(encrypted, decrypted)
+	require.NoError(t, err)
+	assert.Equal(t, secretValues{"hunter2", "t0ken-1", "5432"}, secrets)
+
+	_, err = collectEncryptedValues(encrypted, []byte("password: [hunter2"))
+	assert.EqualError(t, err, "failed to decode decrypted content")
+}
+
+func TestGetValuesLayered(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "defaults",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"values.yaml": "podinfo:\n  replicas: 1\n  ui:\n    color: red\n    message: hello\n  tags: [a, b]\n",
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "credentials",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"values.yaml": []byte("podinfo:\n  password: hunter2\n  tags: [c]\n"),
+		},
+	}
+
+	configuration := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MutationSpec{
+			ValuesFrom: []v1alpha1.ValuesSource{
+				{
+					ConfigMapSource: &v1alpha1.ConfigMapSource{
+						SourceRef: meta.LocalObjectReference{Name: configMap.Name},
+						Key:       "values.yaml",
+						SubPath:   "podinfo",
+					},
+				},
+				{
+					FluxSource: &v1alpha1.FluxValuesSource{
+						SourceRef: meta.NamespacedObjectKindReference{
+							Kind:      "GitRepository",
+							Name:      "missing",
+							Namespace: "default",
+						},
+						Path:     "values.yaml",
+						Optional: true,
+					},
+				},
+				{
+					SecretSource: &v1alpha1.SecretSource{
+						SourceRef: meta.LocalObjectReference{Name: secret.Name},
+						Key:       "values.yaml",
+						SubPath:   "podinfo",
+					},
+				},
+			},
+			Values: &apiextensionsv1.JSON{Raw: []byte(`{"replicas":3,"ui":{"message":"inline"}}`)},
+		},
+	}
+
+	client := env.FakeKubeClient(WithObjects(configMap, secret), WithAddToScheme(sourcev1.AddToScheme))
+	m := &MutationReconcileLooper{
+		Client:    client,
+		APIReader: client,
+	}
+
+	values, secrets, err := m.getValues(context.Background(), configuration, &configuration.Spec)
+	require.NoError(t, err)
+
+	t.Log("verifying that later sources and the inline values take precedence")
+	assert.JSONEq(t, `{
+		"replicas": 3,
+		"password": "hunter2",
+		"tags": ["c"],
+		"ui": {"color": "red", "message": "inline"}
+	}`, string(values.Raw))
+	assert.Equal(t, secretValues{"hunter2", "c"}, secrets)
+
+	t.Log("verifying that the digests of the contributing sources are recorded")
+	sources := configuration.Status.ValuesSources
+	require.Len(t, sources, 2)
+	assert.Equal(t, "ConfigMap/default/defaults", sources[0].Source)
+	assert.NotEmpty(t, sources[0].Version)
+	assert.Equal(t, configMap.ResourceVersion, sources[0].Version)
+	assert.Equal(t, "Secret/default/credentials", sources[1].Source)
+	assert.Equal(t, secret.ResourceVersion, sources[1].Version)
+	assert.Equal(t, digestOf(t, map[string]any{"password": "hunter2", "tags": []any{"c"}}), sources[1].Digest)
+
+	t.Log("verifying that a changed source changes its digest only")
+	configMap.Data["values.yaml"] = "podinfo:\n  replicas: 2\n"
+	require.NoError(t, m.Client.Update(context.Background(), configMap))
+	configuration.Status.ValuesSources = nil
+
+	_, _, err = m.getValues(context.Background(), configuration, &configuration.Spec)
+	require.NoError(t, err)
+	assert.NotEqual(t, sources[0].Digest, configuration.Status.ValuesSources[0].Digest)
+	assert.Equal(t, configMap.ResourceVersion, configuration.Status.ValuesSources[0].Version)
+	assert.Equal(t, sources[1], configuration.Status.ValuesSources[1])
+}
+
+func TestMergeValues(t *testing.T) {
+	dst := map[string]any{
+		"a": map[string]any{"b": 1, "c": map[string]any{"d": 2}},
+		"e": []any{1, 2},
+		"f": "g",
+	}
+	src := map[string]any{
+		"a": map[string]any{"c": map[string]any{"h": 3}},
+		"e": []any{3},
+		"f": map[string]any{"i": 4},
+	}
+
+	assert.Equal(t, map[string]any{
+		"a": map[string]any{"b": 1, "c": map[string]any{"d": 2, "h": 3}},
+		"e": []any{3},
+		"f": map[string]any{"i": 4},
+	}, mergeValues(dst, src))
+}
+
+func digestOf(t *testing.T, values map[string]any) string {
+	t.Helper()
+
+	content, err := json.Marshal(values)
+	require.NoError(t, err)
+
+	return digest.FromBytes(content).String()
+}
+
+func TestValuesFromSingleSource(t *testing.T) {
+	source := v1alpha1.ValuesSource{
+		ConfigMapSource: &v1alpha1.ConfigMapSource{
+			SourceRef: meta.LocalObjectReference{Name: "values"},
+			Key:       "values.yaml",
+			SubPath:   "podinfo",
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		valuesFrom  string
+		expected    v1alpha1.ValuesSources
+		expectError string
+	}{
+		{
+			name: "list of sources",
+			valuesFrom: `
+  - configMapSource:
+      sourceRef:
+        name: values
+      key: values.yaml
+      subPath: podinfo`,
+			expected: v1alpha1.ValuesSources{source},
+		},
+		{
+			name: "single source of previous versions",
+			valuesFrom: `
+    configMapSource:
+      sourceRef:
+        name: values
+      key: values.yaml
+      subPath: podinfo`,
+			expected: v1alpha1.ValuesSources{source},
+		},
+		{
+			name:        "neither a source nor a list",
+			valuesFrom:  ` values.yaml`,
+			expectError: "cannot unmarshal string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration := &v1alpha1.Configuration{}
+			err := yaml.Unmarshal([]byte("spec:\n  valuesFrom:"+tc.valuesFrom+"\n"), configuration)
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, configuration.Spec.ValuesFrom)
+
+			t.Log("verifying that the sources are written as a list")
+			content, err := yaml.Marshal(configuration.Spec)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "valuesFrom:\n- configMapSource:")
+		})
+	}
+}
+
+func TestGetValuesFromConfigurationSnapshot(t *testing.T) {
+	base := &v1alpha1.Configuration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "Configuration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "base",
+			Namespace: "default",
+		},
+		Status: v1alpha1.MutationStatus{
+			SnapshotName: "base-snapshot",
+		},
+	}
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "config"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "values.yaml"), []byte("podinfo:\n  replicas: 2\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "config", "values.yaml"), []byte("podinfo:\n  replicas: 3\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "deployment.yaml"), []byte("kind: Deployment\n"), 0o600))
+
+	t.Log("writing the output of the configuration as a snapshot")
+	fakeCache := &cachefakes.FakeCache{}
+	fakeCache.PushDataReturns("sha256:"+strings.Repeat("a", 64), nil)
+
+	client := env.FakeKubeClient(WithObjects(base))
+	identity := v1.Identity{
+		v1alpha1.ComponentNameKey:   "github.com/open-component-model/podinfo",
+		v1alpha1.ResourceNameKey:    "manifests",
+		v1alpha1.ResourceVersionKey: "v1.0.0",
+	}
+	snapshotDigest, _, err := snapshot.NewOCIWriter(client, fakeCache, env.scheme).Write(context.Background(), base, sourceDir, identity, snapshot.Provenance{})
+	require.NoError(t, err)
+
+	snapshotCR := &v1alpha1.Snapshot{}
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{Name: "base-snapshot", Namespace: "default"}, snapshotCR))
+	snapshotCR.Status.LastReconciledDigest = snapshotDigest
+	require.NoError(t, client.Update(context.Background(), snapshotCR))
+
+	archive := fakeCache.PushDataCallingArgumentsOnCall(0).Content
+	for i := range 3 {
+		fakeCache.FetchDataByDigestReturnsOnCall(i, io.NopCloser(strings.NewReader(archive)), nil)
+	}
+
+	m := &MutationReconcileLooper{
+		Client:        client,
+		APIReader:     client,
+		DynamicClient: env.FakeDynamicKubeClient(WithObjects(base)),
+		Cache:         fakeCache,
+	}
+
+	testCases := []struct {
+		name        string
+		path        string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "default values file",
+			expected: `{"replicas":2}`,
+		},
+		{
+			name:     "values file at path",
+			path:     "./config/values.yaml",
+			expected: `{"replicas":3}`,
+		},
+		{
+			name:        "missing values file",
+			path:        "values-production.yaml",
+			expectError: "failed to get values from source ref: values file values-production.yaml not found in source ref",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration := &v1alpha1.Configuration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.MutationSpec{
+					ValuesFrom: []v1alpha1.ValuesSource{
+						{
+							SourceRef: &v1alpha1.ObjectReference{
+								NamespacedObjectKindReference: meta.NamespacedObjectKindReference{
+									APIVersion: v1alpha1.GroupVersion.String(),
+									Kind:       "Configuration",
+									Name:       base.Name,
+									Namespace:  base.Namespace,
+								},
+							},
+							Path:    tc.path,
+							SubPath: "podinfo",
+						},
+					},
+				},
+			}
+
+			values, _, err := m.getValues(context.Background(), configuration, &configuration.Spec)
+			if tc.expectError != "" {
+				require.EqualError(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(values.Raw))
+			assert.Equal(t, []v1alpha1.ValuesSourceDigest{
+				{
+					Source:  "Configuration/default/base",
+					Digest:  digest.FromString(tc.expected).String(),
+					Version: snapshotDigest,
+				},
+			}, configuration.Status.ValuesSources)
+		})
+	}
+}
+
+func TestGetValuesCrossNamespace(t *testing.T) {
+	newConfigMap := func(name string, annotations map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "platform",
+				Annotations: annotations,
+			},
+			Data: map[string]string{
+				"values.yaml": "region: eu-west-1\n",
+			},
+		}
+	}
+	grant := &v1alpha1.ValuesSourceGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "environment",
+			Namespace: "platform",
+		},
+		Spec: v1alpha1.ValuesSourceGrantSpec{
+			From: []string{"team-a"},
+			To: []v1alpha1.ValuesSourceGrantTarget{
+				{Kind: "ConfigMap", Name: "granted"},
+				{Kind: "Secret"},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "credentials",
+			Namespace: "platform",
+		},
+		Data: map[string][]byte{
+			"values.yaml": []byte("token: t0ken\n"),
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		namespace   string
+		source      v1alpha1.ValuesSource
+		expectError string
+	}{
+		{
+			name:      "granted by annotation",
+			namespace: "team-b",
+			source: v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "annotated"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			},
+		},
+		{
+			name:      "granted to all namespaces by annotation",
+			namespace: "team-c",
+			source: v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "public"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			},
+		},
+		{
+			name:      "granted by grant",
+			namespace: "team-a",
+			source: v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "granted"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			},
+		},
+		{
+			name:      "secret granted by grant of the kind",
+			namespace: "team-a",
+			source: v1alpha1.ValuesSource{
+				SecretSource: &v1alpha1.SecretSource{
+					SourceRef: meta.LocalObjectReference{Name: "credentials"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			},
+		},
+		{
+			name:      "not granted to the namespace",
+			namespace: "team-c",
+			source: v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "annotated"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			},
+			expectError: "ConfigMap platform/annotated doesn't grant namespace team-c access to it as values source",
+		},
+		{
+			name:      "not granted by the grant",
+			namespace: "team-a",
+			source: v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "private"},
+					Namespace: "platform",
+					Key:       "values.yaml",
+				},
+			},
+			expectError: "ConfigMap platform/private doesn't grant namespace team-a access to it as values source",
+		},
+		{
+			name:      "same namespace",
+			namespace: "platform",
+			source: v1alpha1.ValuesSource{
+				ConfigMapSource: &v1alpha1.ConfigMapSource{
+					SourceRef: meta.LocalObjectReference{Name: "private"},
+					Key:       "values.yaml",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := env.FakeKubeClient(WithObjects(
+				newConfigMap("annotated", map[string]string{v1alpha1.ValuesSourceGrantAnnotation: "team-a, team-b"}),
+				newConfigMap("public", map[string]string{v1alpha1.ValuesSourceGrantAnnotation: "*"}),
+				newConfigMap("granted", nil),
+				newConfigMap("private", nil),
+				secret,
+				grant,
+			))
+			m := &MutationReconcileLooper{
+				Client:    client,
+				APIReader: client,
+			}
+
+			configuration := &v1alpha1.Configuration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: tc.namespace,
+				},
+				Spec: v1alpha1.MutationSpec{
+					ValuesFrom: []v1alpha1.ValuesSource{tc.source},
+				},
+			}
+
+			values, _, err := m.getValues(context.Background(), configuration, &configuration.Spec)
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEqual(t, "{}", string(values.Raw))
+			require.Len(t, configuration.Status.ValuesSources, 1)
+			assert.Contains(t, configuration.Status.ValuesSources[0].Source, "/platform/")
+		})
+	}
+}
+
+func TestGetValuesFromEncryptedFluxSource(t *testing.T) {
+	// testdata/sops-values.tar.gz holds config/values.yaml encrypted by the sops CLI for this age identity
+	const (
+		ageKey   = sopsAgeKey
+		otherKey = "AGE-SECRET-KEY-1NHRSZVJ29TWHDRAF95W9FKSCQV5HH3KPL7C6KCCCGRSSNVGD84JQTS3UKP"
+	)
+
+	archive, err := os.ReadFile("testdata/sops-values.tar.gz")
+	require.NoError(t, err)
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.RouteToHandler("GET", "/values.tar.gz", func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write(archive)
+	})
+	gitRepo := createGitRepository("values-repo", "default", server.URL()+"/values.tar.gz", digest.FromBytes(archive).String())
+
+	testCases := []struct {
+		name          string
+		decryption    *v1alpha1.Decryption
+		keys          map[string][]byte
+		expected      string
+		expectSecrets secretValues
+		expectError   string
+	}{
+		{
+			name:          "decrypted with the age identity of the secret",
+			decryption:    &v1alpha1.Decryption{Provider: v1alpha1.SOPSProvider, SecretRef: meta.LocalObjectReference{Name: "sops-keys"}},
+			keys:          map[string][]byte{"identity.agekey": []byte("# test identity\n" + ageKey + "\n"), "other.agekey": []byte(otherKey)},
+			expected:      `{"replicas": 2, "message": "hello from sops", "password": "hunter2"}`,
+			expectSecrets: secretValues{"hello from sops", "hunter2", "2"},
+		},
+		{
+			name:        "unknown age identity",
+			decryption:  &v1alpha1.Decryption{Provider: v1alpha1.SOPSProvider, SecretRef: meta.LocalObjectReference{Name: "sops-keys"}},
+			keys:        map[string][]byte{"identity.agekey": []byte(otherKey)},
+			expectError: "could not decrypt values file: failed to decrypt: Failed to get the data key required to decrypt the SOPS file.",
+		},
+		{
+			name:        "secret without age identities",
+			decryption:  &v1alpha1.Decryption{Provider: v1alpha1.SOPSProvider, SecretRef: meta.LocalObjectReference{Name: "sops-keys"}},
+			keys:        map[string][]byte{"identity.asc": []byte(ageKey)},
+			expectError: "no age identities found in the .agekey keys of secret sops-keys",
+		},
+		{
+			name:        "missing secret",
+			decryption:  &v1alpha1.Decryption{Provider: v1alpha1.SOPSProvider, SecretRef: meta.LocalObjectReference{Name: "missing"}},
+			expectError: "failed to get decryption secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sops-keys",
+					Namespace: "default",
+				},
+				Data: tc.keys,
+			}
+
+			client := env.FakeKubeClient(WithObjects(gitRepo.DeepCopy(), secret), WithAddToScheme(sourcev1.AddToScheme))
+			m := &MutationReconcileLooper{
+				Client:    client,
+				APIReader: client,
+			}
+
+			configuration := &v1alpha1.Configuration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.MutationSpec{
+					ValuesFrom: []v1alpha1.ValuesSource{
+						{
+							FluxSource: &v1alpha1.FluxValuesSource{
+								SourceRef: meta.NamespacedObjectKindReference{
+									Kind:      "GitRepository",
+									Name:      gitRepo.Name,
+									Namespace: gitRepo.Namespace,
+								},
+								Path:    "config/values.yaml",
+								SubPath: "podinfo",
+							},
+						},
+					},
+					Decryption: tc.decryption,
+				},
+			}
+
+			values, secrets, err := m.getValues(context.Background(), configuration, &configuration.Spec)
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(values.Raw))
+			assert.Equal(t, tc.expectSecrets, secrets)
+		})
+	}
+}
+
+// sopsAgeKey is the age identity the SOPS encrypted files of testdata are encrypted for.
+const sopsAgeKey = "AGE-SECRET-KEY-1WSWGR8RMPGLG3G0E7ADW8SPF4QXUSHD320F0VX40FEALZ6XR7YQQKR42HQ"
+
+// sopsOtherAgeKey is the age identity of the second key group of testdata/sops/key-groups.yaml.
+const sopsOtherAgeKey = "AGE-SECRET-KEY-1NHRSZVJ29TWHDRAF95W9FKSCQV5HH3KPL7C6KCCCGRSSNVGD84JQTS3UKP"
+
+func newTestDecryptor(t *testing.T, keys ...string) *decryptor {
+	t.Helper()
+
+	d := &decryptor{}
+	require.NoError(t, d.identities.Import(keys...))
+
+	return d
+}
+
+func TestDecrypt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		keys     []string
+		modify   func(string) string
+		expected string
+		err      string
+	}{
+		{
+			name: "yaml values",
+			file: "values.yaml",
+			keys: []string{sopsAgeKey},
+			expected: `replicas: 3
+message: hello production
+enabled: true
+ratio: 0.5
+database:
+  password: s3cr3t
+  hosts:
+    - db-0.example.com
+    - db-1.example.com
+  port: 5432
+empty: ""
+`,
+		},
+		{
+			name: "multi document yaml",
+			file: "multi.yaml",
+			keys: []string{sopsAgeKey},
+			expected: `apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+stringData:
+  password: s3cr3t
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  created: 2024-01-02T03:04:05Z
+  note: null
+`,
+		},
+		{
+			name:     "json values",
+			file:     "values.json",
+			keys:     []string{sopsAgeKey},
+			expected: `{"replicas": 2, "image": {"tag": "v1.2.3"}, "tls": true}`,
+		},
+		{
+			name: "only matching values encrypted and covered by the mac",
+			file: "partial.yaml",
+			keys: []string{sopsAgeKey},
+			modify: func(s string) string {
+				return strings.Replace(s, "name: backend\n", "name: frontend\n", 1)
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  template:
+    spec:
+      containers:
+        - name: backend
+          env:
+            - name: API_TOKEN
+              value: token-123
+`,
+		},
+		{
+			name: "key groups",
+			file: "key-groups.yaml",
+			keys: []string{sopsAgeKey, sopsOtherAgeKey},
+			expected: `replicas: 2
+database:
+  password: s3cr3t-groups
+`,
+		},
+		{
+			name: "key groups below the threshold",
+			file: "key-groups.yaml",
+			keys: []string{sopsAgeKey},
+			err:  "failed to decrypt",
+		},
+		{
+			name: "unknown key",
+			file: "values.yaml",
+			keys: []string{sopsOtherAgeKey},
+			err:  "failed to decrypt",
+		},
+		{
+			name: "modified plain value",
+			file: "key-groups.yaml",
+			keys: []string{sopsAgeKey, sopsOtherAgeKey},
+			modify: func(s string) string {
+				return strings.Replace(s, "replicas: 2", "replicas: 3", 1)
+			},
+			err: "MAC mismatch",
+		},
+		{
+			name: "moved encrypted value",
+			file: "values.yaml",
+			keys: []string{sopsAgeKey},
+			modify: func(s string) string {
+				return strings.Replace(s, "\nmessage:", "\ngreeting:", 1)
+			},
+			err: "failed to decrypt",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "sops", tc.file))
+			require.NoError(t, err)
+
+			if tc.modify != nil {
+				data = []byte(tc.modify(string(data)))
+			}
+
+			require.True(t, isEncrypted(data))
+
+			decrypted, ok, err := newTestDecryptor(t, tc.keys...).decrypt(data)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.YAMLEq(t, tc.expected, string(decrypted))
+			assert.NotContains(t, string(decrypted), "ENC[")
+			assert.NotContains(t, string(decrypted), "sops")
+		})
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	assert.False(t, isEncrypted([]byte("replicas: 3\n")))
+	assert.False(t, isEncrypted([]byte("- a\n- b\n")))
+	assert.False(t, isEncrypted([]byte("sops: enabled\n")))
+	assert.False(t, isEncrypted([]byte("{")))
+}
+
+func TestDecryptConfigData(t *testing.T) {
+	// only the password of the config data is encrypted
+	encrypted, err := os.ReadFile("testdata/sops-config-data.yaml")
+	require.NoError(t, err)
+
+	d := newTestDecryptor(t, sopsAgeKey)
+
+	t.Log("verifying that the encrypted values are decrypted and collected for redaction")
+	configObj, secrets, err := decryptConfigData(d, encrypted)
+	require.NoError(t, err)
+	assert.Contains(t, string(configObj), "password: hunter2-config")
+	assert.NotContains(t, string(configObj), "sops:")
+	assert.Equal(t, secretValues{"hunter2-config"}, secrets)
+
+	t.Log("verifying that config data which isn't encrypted is used as it is")
+	configObj, secrets, err = decryptConfigData(d, configurationConfigData)
+	require.NoError(t, err)
+	assert.Equal(t, configurationConfigData, configObj)
+	assert.Empty(t, secrets)
+
+	t.Log("verifying that config data isn't decrypted without decryption")
+	configObj, secrets, err = decryptConfigData(nil, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, configObj)
+	assert.Empty(t, secrets)
+
+	_, _, err = decryptConfigData(newTestDecryptor(t, sopsOtherAgeKey), encrypted)
+	assert.ErrorContains(t, err, "failed to decrypt config data")
+}
+
+func TestConfigureRemovesSourceOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	configuration := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MutationSpec{
+			Values: &apiextensionsv1.JSON{Raw: []byte(`{"password":"hunter2"}`)},
+		},
+	}
+
+	m := &MutationReconcileLooper{
+		Client: env.FakeKubeClient(WithObjects(configuration)),
+	}
+
+	_, _, err := m.configure(context.Background(), configuration, []byte("not a tar archive"), configurationConfigData, &configuration.Spec, nil)
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMutatePatchStrategicMergeDecryption(t *testing.T) {
+	// the value of the env variable of sites/eu-west-1/deployment.yaml is encrypted
+	archive, err := os.ReadFile("testdata/sops-patch.tar.gz")
+	require.NoError(t, err)
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.RouteToHandler("GET", "/patch.tar.gz", func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = writer.Write(archive)
+	})
+	gitRepo := createGitRepository("patch-repo", "default", server.URL()+"/patch.tar.gz", digest.FromBytes(archive).String())
+
+	keys := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sops-keys",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{"identity.agekey": []byte(sopsAgeKey)},
+	}
+
+	target, err := os.Open("testdata/merge-target.tar.gz")
+	require.NoError(t, err)
+	defer target.Close()
+	uncompressed, err := gzip.NewReader(target)
+	require.NoError(t, err)
+	sourceData, err := io.ReadAll(uncompressed)
+	require.NoError(t, err)
+
+	configuration := func(targetPath string) *v1alpha1.Configuration {
+		return &v1alpha1.Configuration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "default",
+			},
+			Spec: v1alpha1.MutationSpec{
+				PatchStrategicMerge: &v1alpha1.PatchStrategicMerge{
+					Source: v1alpha1.PatchStrategicMergeSource{
+						SourceRef: meta.NamespacedObjectKindReference{
+							Kind:      "GitRepository",
+							Name:      gitRepo.Name,
+							Namespace: gitRepo.Namespace,
+						},
+						Path: "sites/eu-west-1/deployment.yaml",
+					},
+					Target: v1alpha1.PatchStrategicMergeTarget{
+						Path: targetPath,
+					},
+				},
+				Decryption: &v1alpha1.Decryption{
+					Provider:  v1alpha1.SOPSProvider,
+					SecretRef: meta.LocalObjectReference{Name: keys.Name},
+				},
+			},
+		}
+	}
+
+	client := env.FakeKubeClient(WithObjects(gitRepo, keys), WithAddToScheme(sourcev1.AddToScheme))
+	m := &MutationReconcileLooper{
+		Client:    client,
+		APIReader: client,
+	}
+
+	t.Run("merges the decrypted patch source", func(t *testing.T) {
+		obj := configuration("merge-target/merge-target.yaml")
+		dir, _, secrets, err := m.mutatePatchStrategicMerge(context.Background(), obj, &obj.Spec, sourceData)
+		require.NoError(t, err)
+		defer os.RemoveAll(filepath.Dir(dir))
+
+		t.Log("verifying that the decrypted values are returned for redaction")
+		assert.Contains(t, secrets, "hunter2-patch")
+
+		merged, err := os.ReadFile(filepath.Join(dir, "merge-target", "merge-target.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(merged), "value: hunter2-patch")
+		assert.Contains(t, string(merged), "imagePullPolicy: Always")
+
+		t.Log("verifying that the decrypted patch source isn't part of the result")
+		assert.NoFileExists(t, filepath.Join(dir, "sites", "eu-west-1", "deployment.yaml"))
+	})
+
+	t.Run("removes the decrypted patch source if the merge fails", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		t.Setenv("TMPDIR", tmpDir)
+
+		obj := configuration("missing/missing.yaml")
+		_, _, _, err := m.mutatePatchStrategicMerge(context.Background(), obj, &obj.Spec, sourceData)
+		require.Error(t, err)
+
+		dirs, err := filepath.Glob(filepath.Join(tmpDir, "kustomization-*"))
+		require.NoError(t, err)
+		assert.Empty(t, dirs)
+	})
+}
+
+func TestValidateValues(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["replicas"],
+		"properties": {
+			"replicas": {"type": "integer", "minimum": 1},
+			"logLevel": {"enum": ["debug", "info"]},
+			"database": {
+				"type": "object",
+				"required": ["host"],
+				"properties": {
+					"host": {"type": "string"},
+					"port": {"type": "integer"}
+				}
+			}
+		}
+	}`)
+
+	testCases := []struct {
+		name     string
+		values   string
+		expected []v1alpha1.ValuesValidationError
+	}{
+		{
+			name:   "valid values",
+			values: `{"replicas": 2, "logLevel": "info", "database": {"host": "db", "port": 5432}}`,
+		},
+		{
+			name:   "violations by field path",
+			values: `{"replicas": "two", "logLevel": "trace", "database": {"port": 5432}, "color": "red"}`,
+			expected: []v1alpha1.ValuesValidationError{
+				{Field: "(root)", Type: "additional_property_not_allowed", Message: "Additional property color is not allowed"},
+				{Field: "database.host", Type: "required", Message: "host is required"},
+				{Field: "logLevel", Type: "enum", Message: `logLevel must be one of the following: "debug", "info"`},
+				{Field: "replicas", Type: "invalid_type", Message: "Invalid type. Expected: integer, given: string"},
+			},
+		},
+		{
+			name:   "missing required value",
+			values: `{}`,
+			expected: []v1alpha1.ValuesValidationError{
+				{Field: "replicas", Type: "required", Message: "replicas is required"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := validateValues(schema, []byte(tc.values))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, violations)
+		})
+	}
+
+	_, err := validateValues([]byte(`{"type": 1}`), []byte(`{}`))
+	assert.ErrorContains(t, err, "invalid values schema")
+}
+
+func TestPublishValuesSchema(t *testing.T) {
+	configuration := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+	}
+
+	m := &MutationReconcileLooper{
+		Client: env.FakeKubeClient(WithObjects(configuration)),
+		Scheme: env.scheme,
+	}
+
+	schema := []byte(`{"type":"object","properties":{"replicas":{"type":"integer"}}}`)
+	require.NoError(t, m.publishValuesSchema(context.Background(), configuration, schema))
+
+	t.Log("verifying that the schema is published in a ConfigMap owned by the configuration")
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Name: "test-values-schema", Namespace: "default"}, cm))
+	assert.Equal(t, string(schema), cm.Data["schema.json"])
+	require.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, configuration.UID, cm.OwnerReferences[0].UID)
+	assert.Equal(t, &v1alpha1.ValuesSchema{
+		ConfigMapRef: meta.LocalObjectReference{Name: "test-values-schema"},
+		Digest:       digest.FromBytes(schema).String(),
+	}, configuration.Status.ValuesSchema)
+
+	t.Log("verifying that a changed schema is published")
+	changed := []byte(`{"type":"object","required":["replicas"]}`)
+	require.NoError(t, m.publishValuesSchema(context.Background(), configuration, changed))
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(cm), cm))
+	assert.Equal(t, string(changed), cm.Data["schema.json"])
+	assert.Equal(t, digest.FromBytes(changed).String(), configuration.Status.ValuesSchema.Digest)
+
+	t.Log("verifying that the schema is removed when the config data doesn't declare one")
+	require.NoError(t, m.publishValuesSchema(context.Background(), configuration, nil))
+	assert.Nil(t, configuration.Status.ValuesSchema)
+	err := m.Client.Get(context.Background(), client.ObjectKeyFromObject(cm), cm)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestPublishValuesSchemaDoesNotAdoptConfigMaps(t *testing.T) {
+	configuration := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+	}
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-values-schema",
+			Namespace: "default",
+		},
+		Data: map[string]string{"settings": "owned by a user"},
+	}
+
+	m := &MutationReconcileLooper{
+		Client: env.FakeKubeClient(WithObjects(configuration, existing)),
+		Scheme: env.scheme,
+	}
+
+	err := m.publishValuesSchema(context.Background(), configuration, []byte(`{"type":"object"}`))
+	assert.EqualError(t, err, "failed to publish values schema: ConfigMap test-values-schema already exists and isn't owned by test")
+	assert.Nil(t, configuration.Status.ValuesSchema)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(existing), cm))
+	assert.Equal(t, existing.Data, cm.Data)
+	assert.Empty(t, cm.OwnerReferences)
+
+	t.Log("verifying that a ConfigMap which isn't owned isn't removed")
+	configuration.Status.ValuesSchema = &v1alpha1.ValuesSchema{
+		ConfigMapRef: meta.LocalObjectReference{Name: existing.Name},
+	}
+	require.NoError(t, m.publishValuesSchema(context.Background(), configuration, nil))
+	assert.Nil(t, configuration.Status.ValuesSchema)
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(existing), cm))
+}
+
+func TestCheckValues(t *testing.T) {
+	configData := []byte(`kind: ConfigData
+metadata:
+  name: test-config-data
+configuration:
+  defaults:
+    replicas: 1
+    image: ghcr.io/stefanprodan/podinfo
+  schema:
+    type: object
+    required: [replicas, image]
+    properties:
+      replicas:
+        type: integer
+      image:
+        type: string
+  profiles:
+    broken:
+      defaults:
+        replicas: three
+`)
+
+	testCases := []struct {
+		name        string
+		values      string
+		profile     string
+		expectError string
+	}{
+		{
+			name:   "required values provided by the defaults",
+			values: `{"image":"registry.local/podinfo"}`,
+		},
+		{
+			name:        "invalid values",
+			values:      `{"replicas":"two"}`,
+			expectError: "configurator error: validation failed: replicas: Invalid type. Expected: integer, given: string",
+		},
+		{
+			name:        "invalid defaults of the profile",
+			values:      `{}`,
+			profile:     "broken",
+			expectError: "configurator error: validation failed: replicas: Invalid type. Expected: integer, given: string",
+		},
+		{
+			name:    "values override the defaults of the profile",
+			values:  `{"replicas":3}`,
+			profile: "broken",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration := &v1alpha1.Configuration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+					UID:       "test-uid",
+				},
+			}
+
+			m := &MutationReconcileLooper{
+				Client: env.FakeKubeClient(WithObjects(configuration)),
+				Scheme: env.scheme,
+			}
+
+			err := m.checkValues(context.Background(), configuration, configData, &apiextensionsv1.JSON{Raw: []byte(tc.values)}, tc.profile, nil)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+				assert.NotEmpty(t, configuration.Status.ValuesValidationErrors)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Empty(t, configuration.Status.ValuesValidationErrors)
+		})
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	current := map[string][]byte{
+		"deployment.yaml": []byte("kind: Deployment\nspec:\n  replicas: 1\n"),
+		"removed.yaml":    []byte("kind: ConfigMap\n"),
+		"same.yaml":       []byte("kind: Service\n"),
+		"logo.png":        {0x89, 0x50, 0x4e, 0x47, 0x00},
+		"secret.yaml":     []byte("kind: ConfigMap\n---\nkind: Secret\nstringData:\n  token: generated-1\n"),
+		"template.yaml":   []byte("{{- if .enabled }}\nkind: Secret\ndata:\n  token: {{ .token }}\n{{- end }}\n"),
+	}
+	rendered := map[string][]byte{
+		"deployment.yaml":   []byte("kind: Deployment\nspec:\n  replicas: 3\n"),
+		"same.yaml":         []byte("kind: Service\n"),
+		"logo.png":          {0x89, 0x50, 0x4e, 0x47, 0x01},
+		"config/added.yaml": []byte("password: s3cr3t"),
+		"secret.yaml":       []byte("kind: ConfigMap\n---\nkind: Secret\nstringData:\n  token: generated-2\n"),
+		"template.yaml":     []byte("{{- if .enabled }}\nkind: Secret\ndata:\n  token: {{ .other }}\n{{- end }}\n"),
+	}
+
+	changes, diff := diffFiles(current, rendered, secretValues{"s3cr3t"})
+
+	assert.Equal(t, []v1alpha1.FileChange{
+		{Path: "config/added.yaml", Change: v1alpha1.FileAdded},
+		{Path: "deployment.yaml", Change: v1alpha1.FileChanged},
+		{Path: "logo.png", Change: v1alpha1.FileChanged},
+		{Path: "removed.yaml", Change: v1alpha1.FileRemoved},
+		{Path: "secret.yaml", Change: v1alpha1.FileChanged},
+		{Path: "template.yaml", Change: v1alpha1.FileChanged},
+	}, changes)
+	assert.Equal(t, `--- /dev/null
++++ b/config/added.yaml
+@@ -0,0 +1 @@
++password: **REDACTED**
+--- a/deployment.yaml
++++ b/deployment.yaml
+@@ -1,3 +1,3 @@
+ kind: Deployment
+ spec:
+-  replicas: 1
++  replicas: 3
+Binary files a/logo.png and b/logo.png differ
+--- a/removed.yaml
++++ /dev/null
+@@ -1 +0,0 @@
+-kind: ConfigMap
+Files a/secret.yaml and b/secret.yaml holding Secrets differ, content not shown
+Files a/template.yaml and b/template.yaml holding Secrets differ, content not shown
+`, diff)
+}
+
+func TestDiffFilesSizeLimit(t *testing.T) {
+	large := strings.Repeat("value: abcdefghijklmnopqrstuvwxyz\n", 1024)
+
+	current := map[string][]byte{}
+	rendered := map[string][]byte{}
+	for i := 0; i < 40; i++ {
+		rendered[fmt.Sprintf("file-%02d.yaml", i)] = []byte(large)
+	}
+
+	changes, diff := diffFiles(current, rendered, nil)
+	require.Len(t, changes, 40)
+
+	trailer := "... diffs of 8 files omitted, the size limit has been reached\n"
+	assert.LessOrEqual(t, len(diff), dryRunDiffLimit+len(trailer))
+	assert.True(t, strings.HasSuffix(diff, trailer))
+	assert.Contains(t, diff, "... diff truncated\n")
+	for _, change := range changes {
+		assert.True(t, change.Truncated)
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	assert.Equal(t, "+a\n... diff truncated\n", truncateDiff("+a\n+b\n", 5))
+
+	t.Log("verifying that a line without a line break is cut at a rune boundary")
+	truncated := truncateDiff("+ä", 2)
+	assert.True(t, utf8.ValidString(truncated))
+	assert.Equal(t, "+... diff truncated\n", truncated)
+}
+
+func TestDryRun(t *testing.T) {
+	configuration := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Status: v1alpha1.MutationStatus{
+			SnapshotName:         "test-snapshot",
+			LatestSnapshotDigest: "sha256:current",
+		},
+	}
+	snapshot := &v1alpha1.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.SnapshotSpec{
+			Identity: v1.Identity{v1alpha1.ComponentNameKey: "github.com/open-component-model/test"},
+		},
+		Status: v1alpha1.SnapshotStatus{
+			LastReconciledDigest: "sha256:current",
+		},
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"deployment.yaml": "spec:\n  replicas: 1\n",
+		"service.yaml":    "kind: Service\n",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	fakeCache := &cachefakes.FakeCache{}
+	fakeCache.FetchDataByDigestReturns(io.NopCloser(&archive), nil)
+
+	m := &MutationReconcileLooper{
+		Client: env.FakeKubeClient(WithObjects(configuration, snapshot)),
+		Scheme: env.scheme,
+		Cache:  fakeCache,
+	}
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "deployment.yaml"), []byte("spec:\n  replicas: 3\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "service.yaml"), []byte("kind: Service\n"), 0o600))
+
+	require.NoError(t, m.dryRun(context.Background(), configuration, sourceDir, nil))
+
+	t.Log("verifying that the changes are compared with the current snapshot")
+	args := fakeCache.FetchDataByDigestCallingArgumentsOnCall(0)
+	assert.Equal(t, "sha256:current", args[1])
+	assert.Equal(t, &v1alpha1.DryRunResult{
+		ConfigMapRef:   meta.LocalObjectReference{Name: "test-dry-run"},
+		SnapshotDigest: "sha256:current",
+		Files: []v1alpha1.FileChange{
+			{Path: "deployment.yaml", Change: v1alpha1.FileChanged},
+		},
+	}, configuration.Status.DryRun)
+	assert.Equal(t, "sha256:current", configuration.Status.LatestSnapshotDigest)
+
+	t.Log("verifying that the diff is published in a ConfigMap owned by the configuration")
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKey{Name: "test-dry-run", Namespace: "default"}, cm))
+	assert.Equal(t, `--- a/deployment.yaml
++++ b/deployment.yaml
+@@ -1,2 +1,2 @@
+ spec:
+-  replicas: 1
++  replicas: 3
+`, cm.Data["diff.patch"])
+	require.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, configuration.UID, cm.OwnerReferences[0].UID)
+
+	t.Log("verifying that the dry run is removed once the snapshot is written")
+	require.NoError(t, m.removeDryRun(context.Background(), configuration))
+	assert.Nil(t, configuration.Status.DryRun)
+	err := m.Client.Get(context.Background(), client.ObjectKeyFromObject(cm), cm)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestDryRunDoesNotAdoptConfigMaps(t *testing.T) {
+	configuration := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+	}
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-dry-run",
+			Namespace: "default",
+		},
+		Data: map[string]string{"settings": "owned by a user"},
+	}
+
+	m := &MutationReconcileLooper{
+		Client: env.FakeKubeClient(WithObjects(configuration, existing)),
+		Scheme: env.scheme,
+	}
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "service.yaml"), []byte("kind: Service\n"), 0o600))
+
+	err := m.dryRun(context.Background(), configuration, sourceDir, nil)
+	assert.EqualError(t, err, "failed to publish dry run: ConfigMap test-dry-run already exists and isn't owned by test")
+	assert.Nil(t, configuration.Status.DryRun)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(existing), cm))
+	assert.Equal(t, existing.Data, cm.Data)
+	assert.Empty(t, cm.OwnerReferences)
+
+	t.Log("verifying that a ConfigMap which isn't owned isn't removed")
+	configuration.Status.DryRun = &v1alpha1.DryRunResult{
+		ConfigMapRef: meta.LocalObjectReference{Name: existing.Name},
+	}
+	require.NoError(t, m.removeDryRun(context.Background(), configuration))
+	assert.Nil(t, configuration.Status.DryRun)
+	require.NoError(t, m.Client.Get(context.Background(), client.ObjectKeyFromObject(existing), cm))
+}
+
+func TestConfigurationProfiles(t *testing.T) {
+	configData := `apiVersion: config.ocm.software/v1alpha1
+kind: ConfigData
+metadata:
+  name: test-config-data
+configuration:
+  defaults:
+    replicas: 1
+    logging:
+      level: debug
+      format: text
+  rules:
+  - value: (( replicas ))
+    file: deployment.yaml
+    path: spec.replicas
+  - value: (( logging.level ))
+    file: configmap.yaml
+    path: data.LOG_LEVEL
+  profiles:
+    prod:
+      defaults:
+        replicas: 3
+        logging:
+          level: info
+      rules:
+      - value: (( logging.format ))
+        file: configmap.yaml
+        path: data.LOG_FORMAT
+    staging:
+      defaults:
+        replicas: 2
+`
+
+	testCases := []struct {
+		name        string
+		profile     string
+		values      string
+		expected    map[string]string
+		expectError string
+	}{
+		{
+			name:   "no profile",
+			values: `{}`,
+			expected: map[string]string{
+				"spec.replicas":  "1",
+				"data.LOG_LEVEL": `"debug"`,
+			},
+		},
+		{
+			name:    "profile overlays the defaults and adds rules",
+			profile: "prod",
+			values:  `{}`,
+			expected: map[string]string{
+				"spec.replicas":   "3",
+				"data.LOG_LEVEL":  `"info"`,
+				"data.LOG_FORMAT": `"text"`,
+			},
+		},
+		{
+			name:    "values override the profile",
+			profile: "prod",
+			values:  `{"replicas": 5}`,
+			expected: map[string]string{
+				"spec.replicas":   "5",
+				"data.LOG_LEVEL":  `"info"`,
+				"data.LOG_FORMAT": `"text"`,
+			},
+		},
+		{
+			name:        "unknown profile",
+			profile:     "dev",
+			values:      `{}`,
+			expectError: "profile dev not found in config data, available profiles: [prod, staging]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &MutationReconcileLooper{}
+
+			rules, err := m.createSubstitutionRulesForConfigurationValues([]byte(configData), &apiextensionsv1.JSON{Raw: []byte(tc.values)}, tc.profile)
+			if tc.expectError != "" {
+				require.EqualError(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			result := make(map[string]string)
+			for _, rule := range rules {
+				result[rule.ValuePath] = string(rule.Value)
+			}
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	config := &configdata.ConfigData{
+		Configuration: configdata.ConfigurationSpec{
+			Defaults: map[string]any{
+				"replicas": 1,
+				"logging": map[string]any{
+					"level":  "debug",
+					"format": "text",
+				},
+			},
+			Rules: []configdata.ConfigRule{
+				{Value: "(( replicas ))", File: "deployment.yaml", Path: "spec.replicas"},
+			},
+			Profiles: map[string]configdata.Profile{
+				"prod": {
+					Defaults: map[string]any{
+						"logging": map[string]any{
+							"level": "info",
+						},
+					},
+					Rules: []configdata.ConfigRule{
+						{Value: "(( logging.level ))", File: "configmap.yaml", Path: "data.LOG_LEVEL"},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, applyProfile(config, "prod"))
+	assert.Equal(t, map[string]any{
+		"replicas": 1,
+		"logging": map[string]any{
+			"level":  "info",
+			"format": "text",
+		},
+	}, config.Configuration.Defaults)
+	assert.Equal(t, []configdata.ConfigRule{
+		{Value: "(( replicas ))", File: "deployment.yaml", Path: "spec.replicas"},
+		{Value: "(( logging.level ))", File: "configmap.yaml", Path: "data.LOG_LEVEL"},
+	}, config.Configuration.Rules)
+
+	merged, err := mergedValues([]byte(`{"replicas":1,"logging":{"level":"info","format":"text"}}`), []byte(`{"logging":{"format":"json"}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"replicas":1,"logging":{"level":"info","format":"json"}}`, string(merged))
+}
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{pattern: "deployment.yaml", name: "deployment.yaml", match: true},
+		{pattern: "*.yaml", name: "deployment.yaml", match: true},
+		{pattern: "*.yaml", name: "manifests/deployment.yaml", match: false},
+		{pattern: "manifests/*.yaml", name: "manifests/deployment.yaml", match: true},
+		{pattern: "**/*.yaml", name: "deployment.yaml", match: true},
+		{pattern: "**/*.yaml", name: "manifests/apps/deployment.yaml", match: true},
+		{pattern: "manifests/**", name: "manifests/apps/deployment.yaml", match: true},
+		{pattern: "manifests/**/deployment.yaml", name: "manifests/deployment.yaml", match: true},
+		{pattern: "manifests/**/deployment.yaml", name: "other/deployment.yaml", match: false},
+		{pattern: "manifests/*/service.yaml", name: "manifests/apps/deployment.yaml", match: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.match, matchGlob(tc.pattern, tc.name))
+		})
+	}
+}
+
+func TestSubstituteSelectedDocuments(t *testing.T) {
+	manifests := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  labels:
+    tier: frontend
+spec:
+  replicas: 1 # scaled by the profile
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  labels:
+    tier: backend
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+`
+
+	newSubstitution := func(file, path, value string, selector *configdata.DocumentSelector, strict bool) substitution {
+		return substitution{
+			Substitution: localize.Substitution{
+				FilePath: file,
+				ValueMapping: localize.ValueMapping{
+					Name:      "subst-0",
+					ValuePath: path,
+					Value:     []byte(value),
+				},
+			},
+			rule:     "configuration rule 0",
+			selector: selector,
+			strict:   strict,
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		substitution substitution
+		expected     map[string]string
+		expectError  string
+	}{
+		{
+			name:         "selects documents by kind and name",
+			substitution: newSubstitution("app.yaml", "spec.replicas", "3", &configdata.DocumentSelector{Kind: "Deployment", Name: "frontend"}, false),
+			expected: map[string]string{
+				"app.yaml": strings.Replace(manifests, "replicas: 1 #", "replicas: 3 #", 1),
+			},
+		},
+		{
+			name:         "selects documents by labels",
+			substitution: newSubstitution("app.yaml", "spec.replicas", "2", &configdata.DocumentSelector{LabelSelector: "tier in (backend)"}, false),
+			expected: map[string]string{
+				"app.yaml": strings.Replace(manifests, "  replicas: 1\n", "  replicas: 2\n", 1),
+			},
+		},
+		{
+			name:         "creates the path in the documents of all matching files",
+			substitution: newSubstitution("**/*.yaml", "metadata.namespace", `"apps"`, &configdata.DocumentSelector{Kind: "Service"}, false),
+			expected: map[string]string{
+				"app.yaml":              manifests + "  namespace: apps\n",
+				"overlays/service.yaml": "kind: Service\nmetadata:\n  name: backend\n  namespace: apps\n",
+			},
+		},
+		{
+			name:         "ignores rules matching nothing",
+			substitution: newSubstitution("*.json", "spec.replicas", "3", nil, false),
+			expected: map[string]string{
+				"app.yaml": manifests,
+			},
+		},
+		{
+			name:         "fails strict rules matching no file",
+			substitution: newSubstitution("*.json", "spec.replicas", "3", nil, true),
+			expectError:  "configuration rule 0 matches no file",
+		},
+		{
+			name:         "fails strict rules matching no document",
+			substitution: newSubstitution("app.yaml", "spec.replicas", "3", &configdata.DocumentSelector{Kind: "StatefulSet"}, true),
+			expectError:  "configuration rule 0 matches no document",
+		},
+		{
+			name:         "fails invalid selectors",
+			substitution: newSubstitution("app.yaml", "spec.replicas", "3", &configdata.DocumentSelector{LabelSelector: "tier in frontend"}, false),
+			expectError:  "configuration rule 0 has an invalid selector",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(manifests), 0o600))
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "overlays"), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "overlays", "service.yaml"), []byte("kind: Service\nmetadata:\n  name: backend\n"), 0o600))
+
+			err := substitute(dir, osfs.New(), substitutions{tc.substitution})
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			for file, expected := range tc.expected {
+				content, err := os.ReadFile(filepath.Join(dir, file))
+				require.NoError(t, err)
+				assert.Equal(t, expected, string(content), file)
+			}
+		})
+	}
+}
+
+func TestSubstituteMultiDocumentManifests(t *testing.T) {
+	helmOutput := `---
+# Source: podinfo/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: podinfo
+spec:
+  ports:
+    - {port: 9898, name: http}
+---
+# Source: podinfo/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: podinfo
+spec:
+  replicas: 1 # set by the configuration
+  template:
+    spec:
+      containers:
+        - name: podinfo
+          image: ghcr.io/stefanprodan/podinfo:6.2.0
+---
+# Source: podinfo/templates/hpa.yaml
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: podinfo
+spec:
+  minReplicas: 1
+`
+
+	manifests := `# resources of the frontend
+apiVersion: v1
+kind: ConfigMap
+metadata:
+    name: frontend
+data:
+    color: "red"
+
+
+--- # the frontend
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+spec:
+  replicas: 1
+...
+`
+
+	index := func(i int) *int {
+		return &i
+	}
+
+	testCases := []struct {
+		name        string
+		content     string
+		path        string
+		value       string
+		selector    configdata.DocumentSelector
+		strict      bool
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "the nth document",
+			content:  helmOutput,
+			path:     "spec.replicas",
+			value:    "3",
+			selector: configdata.DocumentSelector{Index: index(1)},
+			expected: strings.Replace(helmOutput, "replicas: 1 #", "replicas: 3 #", 1),
+		},
+		{
+			name:     "a document by apiVersion, kind and name",
+			content:  helmOutput,
+			path:     "spec.minReplicas",
+			value:    "2",
+			selector: configdata.DocumentSelector{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler", Name: "podinfo"},
+			expected: strings.Replace(helmOutput, "minReplicas: 1", "minReplicas: 2", 1),
+		},
+		{
+			name:     "all documents of a name",
+			content:  helmOutput,
+			path:     "metadata.namespace",
+			value:    `"podinfo"`,
+			selector: configdata.DocumentSelector{Name: "podinfo"},
+			expected: strings.ReplaceAll(helmOutput, "  name: podinfo\n", "  name: podinfo\n  namespace: podinfo\n"),
+		},
+		{
+			name:     "empty documents aren't counted",
+			content:  manifests,
+			path:     "spec.replicas",
+			value:    "3",
+			selector: configdata.DocumentSelector{Index: index(1)},
+			expected: strings.Replace(manifests, "replicas: 1\n...\n", "replicas: 3\n", 1),
+		},
+		{
+			name:     "other documents keep their formatting",
+			content:  manifests,
+			path:     "spec.replicas",
+			value:    "3",
+			selector: configdata.DocumentSelector{Kind: "Deployment"},
+			expected: strings.Replace(manifests, "replicas: 1\n...\n", "replicas: 3\n", 1),
+		},
+		{
+			name:     "the modified document is encoded again",
+			content:  manifests,
+			path:     "data.color",
+			value:    `"blue"`,
+			selector: configdata.DocumentSelector{Kind: "ConfigMap"},
+			expected: strings.Replace(manifests, `# resources of the frontend
+apiVersion: v1
+kind: ConfigMap
+metadata:
+    name: frontend
+data:
+    color: "red"
+
+
+`, `# resources of the frontend
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: frontend
+data:
+  color: blue
+`, 1),
+		},
+		{
+			name:     "no matching document",
+			content:  helmOutput,
+			path:     "spec.replicas",
+			value:    "3",
+			selector: configdata.DocumentSelector{Index: index(3)},
+			expected: helmOutput,
+		},
+		{
+			name:        "no matching document in strict mode",
+			content:     helmOutput,
+			path:        "spec.replicas",
+			value:       "3",
+			selector:    configdata.DocumentSelector{APIVersion: "apps/v1beta1", Kind: "Deployment"},
+			strict:      true,
+			expectError: "localization rule 0 matches no document",
+		},
+		{
+			name:        "a path missing in the document",
+			content:     helmOutput,
+			path:        "spec.template.spec.containers[1].image",
+			value:       `"nginx"`,
+			selector:    configdata.DocumentSelector{Kind: "Deployment"},
+			expectError: "failed to substitute spec.template.spec.containers[1].image in document 1 of manifests.yaml: path spec.template.spec.containers[1].image not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, "manifests.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tc.content), 0o600))
+
+			selector := tc.selector
+			subs := targetSubstitutions("localization rule 0", localize.Substitutions{
+				{
+					FilePath: "manifests.yaml",
+					ValueMapping: localize.ValueMapping{
+						Name:      "custom",
+						ValuePath: tc.path,
+						Value:     []byte(tc.value),
+					},
+				},
+			}, &selector, tc.strict)
+
+			err := substitute(dir, osfs.New(), subs)
+			if tc.expectError != "" {
+				require.EqualError(t, err, tc.expectError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(content))
+		})
+	}
+}
+
+func TestPerformMutationRejectsSeveralStrategies(t *testing.T) {
+	configuration := &v1alpha1.Configuration{
+		Spec: v1alpha1.MutationSpec{
+			ConfigRef: &v1alpha1.ObjectReference{},
+			YQ: []v1alpha1.YQExpression{
+				{Path: "*.yaml", Expression: ".spec.replicas = 3"},
+			},
+		},
+	}
+
+	m := &MutationReconcileLooper{}
+	_, _, _, _, err := m.performMutation(context.Background(), configuration, &configuration.Spec, nil)
+	require.EqualError(t, err, "only one mutation strategy can be set, found configRef, yq")
+}
+
+func TestApplyJSON6902PatchSkipsNonKubernetesDocuments(t *testing.T) {
+	patch := kustomize.JSON6902Patch{
+		Target: kustomize.Selector{
+			Kind: "Deployment",
+			Name: "test",
+		},
+		Patch: []kustomize.JSON6902{
+			{
+				Op:    "replace",
+				Path:  "/spec/replicas",
+				Value: &apiextensionsv1.JSON{Raw: []byte("3")},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+
+	values := "replicas: 1\nimage: podinfo\n"
+	valuesFile := filepath.Join(dir, "values.yaml")
+	require.NoError(t, os.WriteFile(valuesFile, []byte(values), 0o600))
+
+	manifestsFile := filepath.Join(dir, "manifests.yaml")
+	require.NoError(t, os.WriteFile(manifestsFile, []byte(`replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+`), 0o600))
+
+	require.NoError(t, applyJSON6902Patch(valuesFile, patch))
+	require.NoError(t, applyJSON6902Patch(manifestsFile, patch))
+
+	content, err := os.ReadFile(valuesFile)
+	require.NoError(t, err)
+	assert.Equal(t, values, string(content), "files without objects are left untouched")
+
+	content, err = os.ReadFile(manifestsFile)
+	require.NoError(t, err)
+	assert.Equal(t, `replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 3
+`, string(content))
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/fluxcd/pkg/runtime/conditions"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -113,6 +114,64 @@ func TestResourceReconciler(t *testing.T) {
 	assert.Contains(t, event, "Reconciliation finished, next run in")
 }
 
+func TestResourceReconcilerDigestMismatch(t *testing.T) {
+	resource := DefaultResource.DeepCopy()
+	resource.Spec.SourceRef.ResourceRef.ReferencePath = nil
+	resource.Status.SnapshotName = "test-resource-lmt3orf"
+
+	cv := DefaultComponent.DeepCopy()
+	cd := DefaultComponentDescriptor.DeepCopy()
+	cv.Status.ComponentDescriptor = v1alpha1.Reference{
+		Name:    resource.Spec.SourceRef.Name,
+		Version: resource.Spec.SourceRef.GetVersion(),
+		ComponentDescriptorRef: meta.NamespacedObjectReference{
+			Name:      cd.Name,
+			Namespace: cd.Namespace,
+		},
+	}
+	conditions.MarkTrue(cv,
+		meta.ReadyCondition,
+		meta.SucceededReason,
+		"Applied version: 1.0.0")
+
+	client := env.FakeKubeClient(WithObjects(cv, resource, cd))
+	cache := &cachefakes.FakeCache{}
+
+	ocmClient := &fakes.MockFetcher{}
+	ocmClient.GetResourceReturns(nil, "", fmt.Errorf("failed to fetch reader for resource: %w", ocm.ErrResourceDigestMismatch))
+
+	rr := ResourceReconciler{
+		Scheme:        env.scheme,
+		Client:        client,
+		OCMClient:     ocmClient,
+		EventRecorder: record.NewFakeRecorder(32),
+		Cache:         cache,
+	}
+
+	_, err := rr.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: resource.Namespace,
+			Name:      resource.Name,
+		},
+	})
+	require.ErrorIs(t, err, ocm.ErrResourceDigestMismatch)
+
+	err = client.Get(context.Background(), types.NamespacedName{
+		Name:      resource.Name,
+		Namespace: resource.Namespace,
+	}, resource)
+	require.NoError(t, err)
+	assert.True(t, conditions.IsFalse(resource, meta.ReadyCondition))
+	assert.Equal(t, v1alpha1.ResourceDigestMismatchReason, conditions.GetReason(resource, meta.ReadyCondition))
+
+	t.Log("verifying no snapshot was created")
+	err = client.Get(context.Background(), types.NamespacedName{
+		Name:      resource.Status.SnapshotName,
+		Namespace: resource.Namespace,
+	}, &v1alpha1.Snapshot{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
 func TestResourceReconcilerWithReferencePath(t *testing.T) {
 	t.Log("setting up resource object")
 	resource := DefaultResource.DeepCopy()
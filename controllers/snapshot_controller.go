@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/patch"
@@ -14,7 +15,9 @@ import (
 	"github.com/open-component-model/ocm-controller/pkg/metrics"
 	"github.com/open-component-model/ocm-controller/pkg/status"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	kuberecorder "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -43,6 +46,13 @@ type SnapshotReconciler struct {
 
 	// InsecureSkipVerify if set, snapshot URL will be http instead of https.
 	InsecureSkipVerify bool
+
+	// VerifyInterval is the interval at which the cached data of a ready snapshot is verified.
+	// Snapshots are only verified when they change if it is zero.
+	VerifyInterval time.Duration
+
+	// RetryInterval is the interval at which snapshots with missing or corrupted data are verified again.
+	RetryInterval time.Duration
 }
 
 //+kubebuilder:rbac:groups=delivery.ocm.software,resources=snapshots,verbs=get;list;watch;create;update;patch;delete
@@ -115,6 +125,17 @@ func (r *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 		return ctrl.Result{}, err
 	}
 
+	if reason, err := r.verifyData(ctx, obj, name); err != nil {
+		status.MarkNotReady(r.EventRecorder, obj, reason, err.Error())
+
+		// the owner re-populates the cache, after which the data is verified again.
+		if rerr := r.requestOwnerReconcile(ctx, obj); rerr != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to request reconcile of snapshot owner: %w", rerr)
+		}
+
+		return ctrl.Result{RequeueAfter: r.RetryInterval}, nil
+	}
+
 	obj.Status.LastReconciledDigest = obj.Spec.Digest
 	obj.Status.LastReconciledTag = obj.Spec.Tag
 
@@ -127,7 +148,60 @@ func (r *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 	status.MarkReady(r.EventRecorder, obj, "Snapshot with name '%s' is ready", obj.Name)
 	metrics.SnapshotReconcileSuccess.WithLabelValues(obj.Name).Inc()
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: r.VerifyInterval}, nil
+}
+
+// verifyData checks that the tag of the snapshot still exists in the cache and that the data
+// behind it matches the snapshot digest. It returns the condition reason alongside the error.
+func (r *SnapshotReconciler) verifyData(ctx context.Context, obj *v1alpha1.Snapshot, name string) (string, error) {
+	cached, err := r.Cache.IsCached(ctx, name, obj.Spec.Tag)
+	if err != nil {
+		return v1alpha1.SnapshotDataMissingReason, fmt.Errorf("failed to check cache for snapshot data: %w", err)
+	}
+
+	if !cached {
+		return v1alpha1.SnapshotDataMissingReason, fmt.Errorf("snapshot data with tag %s is missing from the cache", obj.Spec.Tag)
+	}
+
+	verified, err := r.Cache.VerifyData(ctx, name, obj.Spec.Digest)
+	if err != nil {
+		return v1alpha1.SnapshotDataVerificationFailedReason, fmt.Errorf("failed to verify snapshot data: %w", err)
+	}
+
+	if !verified {
+		return v1alpha1.SnapshotDataVerificationFailedReason, fmt.Errorf("snapshot data doesn't match digest %s", obj.Spec.Digest)
+	}
+
+	return "", nil
+}
+
+// requestOwnerReconcile annotates the owners of the snapshot, so they re-create the snapshot data.
+func (r *SnapshotReconciler) requestOwnerReconcile(ctx context.Context, obj *v1alpha1.Snapshot) error {
+	for _, ref := range obj.GetOwnerReferences() {
+		owner := &metav1.PartialObjectMetadata{}
+		owner.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+		if err := r.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: ref.Name}, owner); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return fmt.Errorf("failed to get owner %s/%s: %w", ref.Kind, ref.Name, err)
+		}
+
+		patch := client.MergeFrom(owner.DeepCopy())
+		annotations := owner.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+		owner.SetAnnotations(annotations)
+
+		if err := r.Patch(ctx, owner, patch); err != nil {
+			return fmt.Errorf("failed to patch owner %s/%s: %w", ref.Kind, ref.Name, err)
+		}
+	}
+
+	return nil
 }
 
 // reconcileDeleteSnapshot removes the cached data that the snapshot was associated with if it exists.
@@ -142,7 +216,24 @@ func (r *SnapshotReconciler) reconcileDeleteSnapshot(ctx context.Context, obj *v
 		return fmt.Errorf("failed to construct name: %w", err)
 	}
 
-	if err := r.Cache.DeleteData(ctx, name, obj.Spec.Tag); err != nil {
+	if err := r.deleteData(ctx, name, obj.Spec.Tag); err != nil {
+		return err
+	}
+
+	for _, revision := range obj.Status.Revisions {
+		if err := r.deleteData(ctx, revision.GetRepository(name), revision.Tag); err != nil {
+			return fmt.Errorf("failed to delete revision %s: %w", revision.Tag, err)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(obj, snapshotFinalizer)
+
+	return patchHelper.Patch(ctx, obj)
+}
+
+// deleteData removes a tag from the cache. Data that is already gone is not considered an error.
+func (r *SnapshotReconciler) deleteData(ctx context.Context, name, tag string) error {
+	if err := r.Cache.DeleteData(ctx, name, tag); err != nil {
 		var terr *transport.Error
 		if !errors.As(err, &terr) {
 			return fmt.Errorf("failure was not a transport error during data deletion: %w", err)
@@ -153,9 +244,7 @@ func (r *SnapshotReconciler) reconcileDeleteSnapshot(ctx context.Context, obj *v
 		}
 	}
 
-	controllerutil.RemoveFinalizer(obj, snapshotFinalizer)
-
-	return patchHelper.Patch(ctx, obj)
+	return nil
 }
 
 func isUnknownManifestError(errors []transport.Diagnostic) bool {
@@ -45,6 +45,8 @@ func TestSnapshotReconciler(t *testing.T) {
 	}
 	client := env.FakeKubeClient(WithObjects(snapshot))
 	fakeCache := &fakes.FakeCache{}
+	fakeCache.IsCachedReturns(true, nil)
+	fakeCache.VerifyDataReturns(true, nil)
 	recorder := record.NewFakeRecorder(32)
 
 	sr := SnapshotReconciler{
@@ -81,6 +83,93 @@ func TestSnapshotReconciler(t *testing.T) {
 	assert.Contains(t, event, "Reconciliation finished")
 }
 
+func TestSnapshotReconcilerVerifiesData(t *testing.T) {
+	testCases := []struct {
+		name           string
+		cached         bool
+		verified       bool
+		verifyErr      error
+		expectedReason string
+	}{
+		{
+			name:           "tag missing from the cache",
+			cached:         false,
+			expectedReason: v1alpha1.SnapshotDataMissingReason,
+		},
+		{
+			name:           "data does not match digest",
+			cached:         true,
+			verified:       false,
+			expectedReason: v1alpha1.SnapshotDataVerificationFailedReason,
+		},
+		{
+			name:           "blob cannot be fetched",
+			cached:         true,
+			verifyErr:      errors.New("blob unknown"),
+			expectedReason: v1alpha1.SnapshotDataVerificationFailedReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			owner := &v1alpha1.Resource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-resource",
+					Namespace: "default",
+				},
+			}
+			snapshot := &v1alpha1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-snapshot",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.SnapshotSpec{
+					Identity: ocmmetav1.Identity{
+						v1alpha1.ComponentNameKey:    "component-name",
+						v1alpha1.ComponentVersionKey: "v0.0.1",
+						v1alpha1.ResourceNameKey:     "resource-name",
+						v1alpha1.ResourceVersionKey:  "v0.0.5",
+					},
+					Digest: "digest-1",
+					Tag:    "1234",
+				},
+			}
+			require.NoError(t, controllerutil.SetOwnerReference(owner, snapshot, env.scheme))
+
+			client := env.FakeKubeClient(WithObjects(snapshot, owner))
+			fakeCache := &fakes.FakeCache{}
+			fakeCache.IsCachedReturns(tc.cached, nil)
+			fakeCache.VerifyDataReturns(tc.verified, tc.verifyErr)
+			recorder := record.NewFakeRecorder(32)
+
+			sr := SnapshotReconciler{
+				Client:              client,
+				Scheme:              env.scheme,
+				RegistryServiceName: "127.0.0.1:5000",
+				EventRecorder:       recorder,
+				Cache:               fakeCache,
+				RetryInterval:       time.Minute,
+			}
+			result, err := sr.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      snapshot.Name,
+					Namespace: snapshot.Namespace,
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, ctrl.Result{RequeueAfter: time.Minute}, result)
+
+			require.NoError(t, client.Get(context.Background(), types.NamespacedName{Name: snapshot.Name, Namespace: snapshot.Namespace}, snapshot))
+			assert.True(t, conditions.IsFalse(snapshot, meta.ReadyCondition))
+			assert.Equal(t, tc.expectedReason, conditions.GetReason(snapshot, meta.ReadyCondition))
+			assert.Empty(t, snapshot.Status.LastReconciledDigest)
+
+			require.NoError(t, client.Get(context.Background(), types.NamespacedName{Name: owner.Name, Namespace: owner.Namespace}, owner))
+			assert.Contains(t, owner.GetAnnotations(), meta.ReconcileRequestAnnotation)
+		})
+	}
+}
+
 func TestSnapshotReconcilerDelete(t *testing.T) {
 	snapshot := &v1alpha1.Snapshot{
 		ObjectMeta: metav1.ObjectMeta{
@@ -100,6 +189,14 @@ func TestSnapshotReconcilerDelete(t *testing.T) {
 			Digest: "digest-1",
 			Tag:    "1234",
 		},
+		Status: v1alpha1.SnapshotStatus{
+			Revisions: []v1alpha1.SnapshotRevision{
+				{
+					Digest: "digest-1",
+					Tag:    "1234-digest-1",
+				},
+			},
+		},
 	}
 	controllerutil.AddFinalizer(snapshot, snapshotFinalizer)
 	client := env.FakeKubeClient(WithObjects(snapshot))
@@ -123,6 +220,8 @@ func TestSnapshotReconcilerDelete(t *testing.T) {
 	assert.Equal(t, ctrl.Result{}, result)
 	err = client.Get(context.Background(), types.NamespacedName{Name: snapshot.Name, Namespace: snapshot.Namespace}, snapshot)
 	assert.True(t, apierror.IsNotFound(err))
+	assert.Equal(t, "1234", fakeCache.DeleteDataCallingArgumentsOnCall(0)[1])
+	assert.Equal(t, "1234-digest-1", fakeCache.DeleteDataCallingArgumentsOnCall(1)[1])
 }
 
 func TestSnapshotReconcilerDeleteFails(t *testing.T) {
@@ -0,0 +1,426 @@
+package controllers
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/labels"
+	"ocm.software/ocm/api/ocm/ocmutils/localize"
+
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+)
+
+// substitution is a substitution of a configuration or localization rule. The file of the rule may
+// be a glob pattern and the rule may be restricted to the documents matching a selector.
+type substitution struct {
+	localize.Substitution
+	// rule names the rule of the substitution in errors, e.g. "configuration rule 2".
+	rule     string
+	selector *configdata.DocumentSelector
+	strict   bool
+	// image is the image the resource of a localization rule resolves to, if it is an OCI artifact.
+	image string
+}
+
+type substitutions []substitution
+
+// targetSubstitutions returns the substitutions of a rule with the files and documents targeted by the rule.
+func targetSubstitutions(rule string, rules localize.Substitutions, selector *configdata.DocumentSelector, strict bool) substitutions {
+	result := make(substitutions, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, substitution{
+			Substitution: r,
+			rule:         rule,
+			selector:     selector,
+			strict:       strict,
+		})
+	}
+
+	return result
+}
+
+// plainKeyPath matches paths made of mapping keys, which are created if they don't exist.
+var plainKeyPath = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// substitute applies the substitutions to the files of dir. Substitutions of a single file are applied
+// by localize.Substitute. Glob patterns are expanded to the matching files and substitutions selecting
+// documents are applied to the matching documents of the files, after the other substitutions.
+func substitute(dir string, fs vfs.FileSystem, subs substitutions) error {
+	plain, selected, err := subs.expand(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := localize.Substitute(plain, fs); err != nil {
+		return err
+	}
+
+	matched := map[string]bool{}
+	for _, s := range selected {
+		ok, err := s.substituteDocuments(dir)
+		if err != nil {
+			return err
+		}
+
+		matched[s.rule] = matched[s.rule] || ok
+	}
+
+	for _, s := range selected {
+		if s.strict && !matched[s.rule] {
+			return fmt.Errorf("%s matches no document", s.rule)
+		}
+	}
+
+	return nil
+}
+
+// expand resolves the glob patterns of the substitutions against dir. It returns the substitutions
+// of single files and the substitutions selecting documents.
+func (s substitutions) expand(dir string) (localize.Substitutions, substitutions, error) {
+	var (
+		plain    localize.Substitutions
+		selected substitutions
+	)
+
+	for _, sub := range s {
+		files := []string{sub.FilePath}
+		if isGlob(sub.FilePath) {
+			var err error
+			if files, err = matchFiles(dir, sub.FilePath); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", sub.rule, err)
+			}
+
+			if len(files) == 0 && sub.strict {
+				return nil, nil, fmt.Errorf("%s matches no file", sub.rule)
+			}
+		}
+
+		for _, file := range files {
+			expanded := sub
+			expanded.FilePath = file
+
+			if sub.selector != nil {
+				selected = append(selected, expanded)
+
+				continue
+			}
+
+			plain = append(plain, expanded.Substitution)
+		}
+	}
+
+	return plain, selected, nil
+}
+
+// substituteDocuments substitutes the value in the documents of the file matching the selector and
+// reports whether any document matched. The documents which don't match keep their formatting.
+func (s substitution) substituteDocuments(dir string) (bool, error) {
+	selector, err := parseSelector(s.selector)
+	if err != nil {
+		return false, fmt.Errorf("%s has an invalid selector: %w", s.rule, err)
+	}
+
+	filePath, err := securejoin.SecureJoin(dir, s.FilePath)
+	if err != nil {
+		return false, err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", s.FilePath, err)
+	}
+
+	file, err := parseYAMLFile(content)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", s.FilePath, err)
+	}
+
+	matched := false
+	for i, doc := range file.documents() {
+		if !selector.matches(i, doc.doc) {
+			continue
+		}
+
+		if err := setValue(doc.doc, s.ValuePath, s.Value); err != nil {
+			return false, fmt.Errorf("failed to substitute %s in document %d of %s: %w", s.ValuePath, i, s.FilePath, err)
+		}
+
+		doc.modified = true
+		matched = true
+	}
+
+	if !matched {
+		return false, nil
+	}
+
+	out, err := file.bytes()
+	if err != nil {
+		return false, fmt.Errorf("failed to encode %s: %w", s.FilePath, err)
+	}
+
+	return true, os.WriteFile(filePath, out, FSOwnerReadWrite)
+}
+
+// documentSelector is a parsed document selector.
+type documentSelector struct {
+	index      *int
+	apiVersion string
+	kind       string
+	name       string
+	labels     labels.Selector
+}
+
+func parseSelector(selector *configdata.DocumentSelector) (*documentSelector, error) {
+	result := &documentSelector{
+		index:      selector.Index,
+		apiVersion: selector.APIVersion,
+		kind:       selector.Kind,
+		name:       selector.Name,
+		labels:     labels.Everything(),
+	}
+
+	if selector.LabelSelector != "" {
+		var err error
+		if result.labels, err = labels.Parse(selector.LabelSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// matches reports whether the document at index matches the selector.
+func (s *documentSelector) matches(index int, doc *yaml.Node) bool {
+	if s.index != nil && *s.index != index {
+		return false
+	}
+
+	if s.apiVersion != "" && lookupScalar(doc, "apiVersion") != s.apiVersion {
+		return false
+	}
+
+	if s.kind != "" && lookupScalar(doc, "kind") != s.kind {
+		return false
+	}
+
+	if s.name != "" && lookupScalar(doc, "metadata", "name") != s.name {
+		return false
+	}
+
+	return s.labels.Matches(documentLabels(doc))
+}
+
+// documentLabels returns the labels of the object held by doc.
+func documentLabels(doc *yaml.Node) labels.Set {
+	set := labels.Set{}
+
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, key := range []string{"metadata", "labels"} {
+		node = mappingValue(node, key)
+		if node == nil {
+			return set
+		}
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return set
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		set[node.Content[i].Value] = node.Content[i+1].Value
+	}
+
+	return set
+}
+
+// mappingValue returns the value of key in the mapping node, if any.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// setValue replaces the nodes at path in doc with the JSON encoded value. Paths made of mapping keys
+// are created if they don't exist.
+func setValue(doc *yaml.Node, valuePath string, value []byte) error {
+	replacement := &yaml.Node{}
+	if err := yaml.Unmarshal(value, replacement); err != nil {
+		return fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	if replacement.Kind != yaml.DocumentNode || len(replacement.Content) == 0 {
+		return fmt.Errorf("value is empty")
+	}
+
+	resetStyle(replacement)
+
+	expression := valuePath
+	if !strings.HasPrefix(expression, "$") {
+		expression = "$." + expression
+	}
+
+	p, err := yamlpath.NewPath(expression)
+	if err != nil {
+		return fmt.Errorf("invalid path %s: %w", valuePath, err)
+	}
+
+	nodes, err := p.Find(doc)
+	if err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		if !plainKeyPath.MatchString(valuePath) {
+			return fmt.Errorf("path %s not found", valuePath)
+		}
+
+		node, err := createPath(doc, strings.Split(valuePath, "."))
+		if err != nil {
+			return err
+		}
+
+		nodes = []*yaml.Node{node}
+	}
+
+	for _, node := range nodes {
+		head, line, foot := node.HeadComment, node.LineComment, node.FootComment
+		*node = *replacement.Content[0]
+		node.HeadComment, node.LineComment, node.FootComment = head, line, foot
+	}
+
+	return nil
+}
+
+// createPath creates the mapping keys of path in doc and returns the node of the last key.
+func createPath(doc *yaml.Node, keys []string) (*yaml.Node, error) {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		}
+
+		doc = doc.Content[0]
+	}
+
+	node := doc
+	for i, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(keys[:i], "."))
+		}
+
+		next := mappingValue(node, key)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, next)
+		}
+
+		node = next
+	}
+
+	return node, nil
+}
+
+// resetStyle removes the JSON quoting and flow style of the decoded value.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// isGlob reports whether the file of a rule is a glob pattern.
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// matchFiles returns the files of dir matching the glob pattern sorted by name.
+func matchFiles(dir, pattern string) ([]string, error) {
+	pattern = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(pattern)), "/")
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid file pattern %s: %w", pattern, err)
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		if rel = filepath.ToSlash(rel); matchGlob(pattern, rel) {
+			files = append(files, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to match files: %w", err)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// matchGlob reports whether name matches the pattern. The segments of the pattern are matched by
+// path.Match, except "**" which matches any number of segments.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// documentSeparator matches the lines separating the documents of a YAML file, e.g. "---" or "--- # comment".
+var documentSeparator = regexp.MustCompile(`^---(\s.*)?$`)
+
+// yamlFile is a multi document YAML file whose documents can be modified individually. Only the
+// modified documents are encoded again, the other documents, the separators and the comments between
+// the documents keep their formatting.
+type yamlFile struct {
+	chunks []*yamlChunk
+}
+
+// yamlChunk is the content between two document separators.
+type yamlChunk struct {
+	// separator is the separator line preceding the chunk, empty for the first chunk of a file not
+	// starting with a separator.
+	separator []byte
+	raw       []byte
+	// doc is the decoded document, nil if the chunk holds only comments or whitespace.
+	doc      *yaml.Node
+	modified bool
+}
+
+// parseYAMLFile splits content into its documents.
+func parseYAMLFile(content []byte) (*yamlFile, error) {
+	file := &yamlFile{}
+	chunk := &yamlChunk{}
+
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if documentSeparator.Match(bytes.TrimRight(line, "\r\n")) {
+			file.chunks = append(file.chunks, chunk)
+			chunk = &yamlChunk{separator: line}
+
+			continue
+		}
+
+		chunk.raw = append(chunk.raw, line...)
+	}
+
+	file.chunks = append(file.chunks, chunk)
+
+	for i, chunk := range file.chunks {
+		doc, err := decodeChunk(chunk.raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode document %d: %w", i, err)
+		}
+
+		chunk.doc = doc
+	}
+
+	return file, nil
+}
+
+// decodeChunk decodes the single document of a chunk, if any.
+func decodeChunk(raw []byte) (*yaml.Node, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+
+	doc := &yaml.Node{}
+	if err := decoder.Decode(doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if err := decoder.Decode(&yaml.Node{}); !errors.Is(err, io.EOF) {
+		return nil, errors.New("expected a single document")
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	return doc, nil
+}
+
+// documents returns the documents of the file in order, skipping the empty ones.
+func (f *yamlFile) documents() []*yamlChunk {
+	var docs []*yamlChunk
+	for _, chunk := range f.chunks {
+		if chunk.doc != nil {
+			docs = append(docs, chunk)
+		}
+	}
+
+	return docs
+}
+
+// bytes returns the content of the file with the modified documents encoded again.
+func (f *yamlFile) bytes() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, chunk := range f.chunks {
+		buf.Write(chunk.separator)
+
+		if !chunk.modified {
+			buf.Write(chunk.raw)
+
+			continue
+		}
+
+		out, err := encodeDocuments([]*yaml.Node{chunk.doc})
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(out)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
 	"github.com/open-component-model/ocm-controller/controllers"
+	"github.com/open-component-model/ocm-controller/pkg/helm/sdk"
 	"github.com/open-component-model/ocm-controller/pkg/oci"
 	"github.com/open-component-model/ocm-controller/pkg/ocm"
 	"github.com/open-component-model/ocm-controller/pkg/snapshot"
@@ -62,6 +63,9 @@ func main() {
 		ociRegistryCertSecretName     string
 		ociRegistryInsecureSkipVerify bool
 		ociRegistryNamespace          string
+		snapshotSigningSecretName     string
+		snapshotRetention             int
+		snapshotVerifyInterval        time.Duration
 		kubeAPIQPS                    float64
 		kubeAPIBurst                  int
 		kubeAPIRateLimiterDisabled    bool
@@ -110,6 +114,26 @@ func main() {
 		false,
 		"Skip verification of the certificate that the registry is using.",
 	)
+	flag.StringVar(
+		&snapshotSigningSecretName,
+		"snapshot-signing-secret-name",
+		"",
+		"The name of the Secret in the registry namespace holding the cosign key pair used to sign snapshots. "+
+			"Signing and verification of snapshots by Flux is disabled if empty.",
+	)
+	flag.IntVar(
+		&snapshotRetention,
+		"snapshot-retention",
+		0,
+		"The number of previous snapshot revisions kept in the cache per owner to allow rollbacks. "+
+			"Revisions are not retained if set to 0.",
+	)
+	flag.DurationVar(
+		&snapshotVerifyInterval,
+		"snapshot-verify-interval",
+		10*time.Minute,
+		"The interval at which the cached data of snapshots is verified. Snapshots with missing data are re-created by their owner.",
+	)
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -178,7 +202,18 @@ func main() {
 		startPprof(pprofAddr)
 	}
 
-	setupManagers(ociRegistryAddr, mgr, ociRegistryNamespace, ociRegistryCertSecretName, ociRegistryInsecureSkipVerify, restConfig, eventsAddr)
+	setupManagers(
+		ociRegistryAddr,
+		mgr,
+		ociRegistryNamespace,
+		ociRegistryCertSecretName,
+		ociRegistryInsecureSkipVerify,
+		snapshotSigningSecretName,
+		snapshotRetention,
+		snapshotVerifyInterval,
+		restConfig,
+		eventsAddr,
+	)
 
 	//+kubebuilder:scaffold:builder
 
@@ -204,6 +239,9 @@ func setupManagers(
 	mgr manager.Manager,
 	ociRegistryNamespace, ociRegistryCertSecretName string,
 	ociRegistryInsecureSkipVerify bool,
+	snapshotSigningSecretName string,
+	snapshotRetention int,
+	snapshotVerifyInterval time.Duration,
 	restConfig *rest.Config,
 	eventsAddr string,
 ) {
@@ -216,6 +254,20 @@ func setupManagers(
 	)
 	ocmClient := ocm.NewClient(mgr.GetClient(), cache)
 	snapshotWriter := snapshot.NewOCIWriter(mgr.GetClient(), cache, mgr.GetScheme())
+	snapshotWriter.Retention = snapshotRetention
+
+	var snapshotSigner snapshot.Signer
+	if snapshotSigningSecretName != "" {
+		snapshotSigner = snapshot.NewCosignSigner(
+			mgr.GetClient(),
+			cache,
+			ociRegistryAddr,
+			snapshotSigningSecretName,
+			ociRegistryNamespace,
+		)
+		snapshotWriter.Signer = snapshotSigner
+	}
+
 	dynClient, err := dynamic.NewForConfig(restConfig)
 	if err != nil {
 		setupLog.Error(err, "unable to get dynamic config client", "controller", "ocm-controller")
@@ -245,6 +297,8 @@ func setupManagers(
 		RegistryServiceName: ociRegistryAddr,
 		Cache:               cache,
 		InsecureSkipVerify:  ociRegistryInsecureSkipVerify,
+		VerifyInterval:      snapshotVerifyInterval,
+		RetryInterval:       time.Minute,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Snapshot")
 		os.Exit(1)
@@ -263,11 +317,13 @@ func setupManagers(
 
 	mutationReconciler := controllers.MutationReconcileLooper{
 		Client:         mgr.GetClient(),
+		APIReader:      mgr.GetAPIReader(),
 		Scheme:         mgr.GetScheme(),
 		OCMClient:      ocmClient,
 		DynamicClient:  dynClient,
 		Cache:          cache,
 		SnapshotWriter: snapshotWriter,
+		ChartRenderer:  sdk.NewTemplateRenderer(),
 	}
 
 	if err = (&controllers.LocalizationReconciler{
@@ -308,6 +364,7 @@ func setupManagers(
 		RegistryServiceName: ociRegistryAddr,
 		CertSecretName:      ociRegistryCertSecretName,
 		Cache:               cache,
+		Signer:              snapshotSigner,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "FluxDeployer")
 		os.Exit(1)
@@ -0,0 +1,48 @@
+package cache
+
+// Artifact types declared on manifests pushed into the cache.
+const (
+	// SnapshotArtifactType is the artifact type of snapshots produced by mutation objects.
+	SnapshotArtifactType = "application/vnd.ocm.software.snapshot.v1+json"
+	// ResourceArtifactType is the artifact type of component resources fetched through OCM.
+	ResourceArtifactType = "application/vnd.ocm.software.resource.v1+json"
+)
+
+// Content media types of the single layer carried by cached artifacts.
+const (
+	// TarContentMediaType describes a tarball of a directory, e.g. a set of kustomize manifests.
+	TarContentMediaType = "application/vnd.ocm.software.content.v1.tar"
+	// BlobContentMediaType describes opaque resource content.
+	BlobContentMediaType = "application/vnd.ocm.software.content.v1.blob"
+)
+
+// OCM specific annotations added to cached artifacts next to the standard
+// org.opencontainers.image.* annotations.
+const (
+	AnnotationComponentName    = "software.ocm.component.name"
+	AnnotationComponentVersion = "software.ocm.component.version"
+	AnnotationResourceIdentity = "software.ocm.resource.identity"
+	AnnotationSourceDigest     = "software.ocm.source.digest"
+	AnnotationConfigDigest     = "software.ocm.config.digest"
+	AnnotationOwner            = "software.ocm.owner"
+)
+
+// PushOptions describe how data is stored as an OCI artifact.
+type PushOptions struct {
+	// ArtifactType is declared as the config media type of the manifest.
+	ArtifactType string
+	// MediaType of the content layer. Defaults to an OCI layer.
+	MediaType string
+	// Annotations are added to the manifest.
+	Annotations map[string]string
+}
+
+// PushResult describes an artifact pushed into the cache.
+type PushResult struct {
+	// Digest of the content layer.
+	Digest string
+	// Size of the content layer.
+	Size int64
+	// ManifestDigest is the digest of the pushed manifest, which is what signatures refer to.
+	ManifestDigest string
+}
@@ -9,7 +9,9 @@ import (
 type Cache interface {
 	IsCached(ctx context.Context, name, tag string) (bool, error)
 	PushData(ctx context.Context, data io.ReadCloser, mediaType, name, tag string) (string, int64, error)
+	PushArtifact(ctx context.Context, data io.ReadCloser, name, tag string, opts PushOptions) (PushResult, error)
 	FetchDataByIdentity(ctx context.Context, name, tag string) (io.ReadCloser, string, int64, error)
 	FetchDataByDigest(ctx context.Context, name, digest string) (io.ReadCloser, error)
 	DeleteData(ctx context.Context, name, tag string) error
+	VerifyData(ctx context.Context, name, digest string) (bool, error)
 }
@@ -21,6 +21,7 @@ type FakeCache struct {
 	pushDataString                string
 	pushDataSize                  int64
 	pushDataErr                   error
+	pushDataManifestDigest        string
 	pushDataCalledWith            []PushDataArguments
 	fetchDataByIdentityReader     io.ReadCloser
 	fetchDataByIdentityDigest     string
@@ -31,6 +32,9 @@ type FakeCache struct {
 	fetchDataByDigestCalledWith   [][]any
 	deleteDataErr                 error
 	deleteDataCalledWith          [][]any
+	verifyDataBool                bool
+	verifyDataErr                 error
+	verifyDataCalledWith          [][]any
 }
 
 func (f *FakeCache) IsCached(ctx context.Context, name, tag string) (bool, error) {
@@ -52,13 +56,30 @@ func (f *FakeCache) IsCachedWasNotCalled() bool {
 }
 
 func (f *FakeCache) PushData(ctx context.Context, data io.ReadCloser, mediaType, name, tag string) (string, int64, error) {
+	result, err := f.PushArtifact(ctx, data, name, tag, cache.PushOptions{MediaType: mediaType})
+
+	return result.Digest, result.Size, err
+}
+
+func (f *FakeCache) PushArtifact(ctx context.Context, data io.ReadCloser, name, tag string, opts cache.PushOptions) (cache.PushResult, error) {
 	content, err := io.ReadAll(data)
 	if err != nil {
-		return "", -1, fmt.Errorf("failed to read read closer: %w", err)
+		return cache.PushResult{Size: -1}, fmt.Errorf("failed to read read closer: %w", err)
 	}
 
-	f.pushDataCalledWith = append(f.pushDataCalledWith, PushDataArguments{Content: string(content), Name: name, Version: tag})
-	return f.pushDataString, f.pushDataSize, f.pushDataErr
+	f.pushDataCalledWith = append(f.pushDataCalledWith, PushDataArguments{
+		Content:      string(content),
+		Name:         name,
+		Version:      tag,
+		ArtifactType: opts.ArtifactType,
+		MediaType:    opts.MediaType,
+		Annotations:  opts.Annotations,
+	})
+	return cache.PushResult{
+		Digest:         f.pushDataString,
+		Size:           f.pushDataSize,
+		ManifestDigest: f.pushDataManifestDigest,
+	}, f.pushDataErr
 }
 
 func (f *FakeCache) PushDataReturns(digest string, err error) {
@@ -66,10 +87,18 @@ func (f *FakeCache) PushDataReturns(digest string, err error) {
 	f.pushDataErr = err
 }
 
+// PushDataReturnsManifestDigest sets the manifest digest returned by PushArtifact.
+func (f *FakeCache) PushDataReturnsManifestDigest(digest string) {
+	f.pushDataManifestDigest = digest
+}
+
 type PushDataArguments struct {
-	Name    string
-	Version string
-	Content string
+	Name         string
+	Version      string
+	Content      string
+	ArtifactType string
+	MediaType    string
+	Annotations  map[string]string
 }
 
 func (f *FakeCache) PushDataCallingArgumentsOnCall(i int) PushDataArguments {
@@ -153,4 +182,22 @@ func (f *FakeCache) DeleteDataWasNotCalled() bool {
 	return len(f.deleteDataCalledWith) == 0
 }
 
+func (f *FakeCache) VerifyData(ctx context.Context, name, digest string) (bool, error) {
+	f.verifyDataCalledWith = append(f.verifyDataCalledWith, []any{name, digest})
+	return f.verifyDataBool, f.verifyDataErr
+}
+
+func (f *FakeCache) VerifyDataReturns(verified bool, err error) {
+	f.verifyDataBool = verified
+	f.verifyDataErr = err
+}
+
+func (f *FakeCache) VerifyDataCallingArgumentsOnCall(i int) []any {
+	return f.verifyDataCalledWith[i]
+}
+
+func (f *FakeCache) VerifyDataWasNotCalled() bool {
+	return len(f.verifyDataCalledWith) == 0
+}
+
 var _ cache.Cache = &FakeCache{}
@@ -1,7 +1,8 @@
 package configdata
 
 import (
-	"github.com/xeipuuv/gojsonschema"
+	"encoding/json"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,7 +26,18 @@ configuration:
     additionalProperties: false
     properties:
       replicas:
-        type: string
+        type: integer
+  render:
+    engine: jsonnet
+    path: config/main.jsonnet
+  profiles:
+    prod:
+      defaults:
+        replicas: 3
+      rules:
+      - value: (( replicas ))
+        file: hpa.yaml
+        path: spec.minReplicas
 localization:
 - file: helm_release.yaml
   tag: spec.chart.spec.version
@@ -61,11 +73,18 @@ localization:
 // - **plain yaml substitution**
 // - **cue lang** ( https://cuelang.org/ ) with a playground (https://cuelang.org/play/)
 // - **strategic patch merge**
+// - **jsonnet** ( https://jsonnet.org/ ) and **cue modules** as configuration programs
+// The file of a configuration or localization rule may be a glob pattern, with "**" matching any
+// number of directories, and a rule may select the documents of multi document YAML files by index,
+// apiVersion, kind, name and labels. The rule applies to every matching file and document.
 // The available Localization resource properties are:
 // - **image**
 // - **repository**
 // - **registry**
-// - **tag**.
+// - **tag**
+// - **digest**
+// - **url**, **host**, **path** and **ref** of Helm repositories, S3 and HTTP blobs, git repositories
+// and snapshots of Resource objects.
 type ConfigData struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitzero"`
@@ -74,15 +93,62 @@ type ConfigData struct {
 }
 
 type ConfigurationSpec struct {
-	Defaults map[string]any      `json:"defaults"`
-	Schema   gojsonschema.Schema `json:"schema"`
-	Rules    []ConfigRule        `json:"rules"`
+	Defaults map[string]any `json:"defaults"`
+	// Schema is the JSON schema validating the configuration values merged over the defaults.
+	Schema json.RawMessage `json:"schema,omitempty"`
+	Rules  []ConfigRule    `json:"rules"`
+	Render *Render         `json:"render,omitempty"`
+	// Profiles are named overlays of the defaults and rules, e.g. one per environment. A Configuration
+	// selects at most one profile.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+}
+
+// Profile overlays the defaults of the configuration with its defaults and adds its rules after the
+// rules of the configuration. The schema validates the values merged with the defaults of the profile.
+type Profile struct {
+	Defaults map[string]any `json:"defaults,omitempty"`
+	Rules    []ConfigRule   `json:"rules,omitempty"`
+}
+
+// RenderEngine is the engine used to evaluate a configuration program.
+type RenderEngine string
+
+const (
+	// JsonnetEngine evaluates a Jsonnet file.
+	JsonnetEngine RenderEngine = "jsonnet"
+	// CUEEngine evaluates a CUE package.
+	CUEEngine RenderEngine = "cue"
+)
+
+// Render declares a configuration program shipped with the source. The program is evaluated
+// after the substitution rules and is given the configuration values merged with the defaults,
+// the component descriptor and the source files. It produces an object mapping file names to
+// their content, which are written into the snapshot.
+type Render struct {
+	Engine RenderEngine `json:"engine"`
+	// Path is the Jsonnet file or the directory of the CUE package within the source.
+	Path string `json:"path"`
 }
 
 type ConfigRule struct {
 	Value any    `json:"value"`
 	Path  string `json:"path"`
 	File  string `json:"file"`
+	// Select restricts the rule to the documents of the files matching the selector.
+	Select *DocumentSelector `json:"select,omitempty"`
+	// Strict fails the configuration if the rule matches no file or document.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// DocumentSelector selects the documents of multi document YAML files. Empty fields match all documents.
+type DocumentSelector struct {
+	// Index selects the document by its position in the file, counted from 0. Empty documents aren't counted.
+	Index      *int   `json:"index,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	// LabelSelector is a Kubernetes label selector, e.g. "app=podinfo,tier in (frontend,backend)".
+	LabelSelector string `json:"labelSelector,omitempty"`
 }
 
 type LocalizationRule struct {
@@ -94,6 +160,39 @@ type LocalizationRule struct {
 	FullyQualifiedRepository string       `json:"fullyQualifiedRepository,omitempty"`
 	Image                    string       `json:"image,omitempty"`
 	Tag                      string       `json:"tag,omitempty"`
+	Digest                   string       `json:"digest,omitempty"`
+	// PinDigest substitutes the image as repository@digest, even if the resource only refers to a tag.
+	PinDigest bool `json:"pinDigest,omitempty"`
+	// URL, Host, Path and Ref localize the location of resources which aren't OCI images, such as
+	// Helm repositories, S3 or HTTP blobs and git repositories.
+	URL  string `json:"url,omitempty"`
+	Host string `json:"host,omitempty"`
+	Path string `json:"path,omitempty"`
+	Ref  string `json:"ref,omitempty"`
+	// Snapshot localizes the location of the snapshot of a Resource object in the cluster instead of
+	// a resource of the component.
+	Snapshot *SnapshotItem `json:"snapshot,omitempty"`
+	// Platform selects the manifest of a multi-arch image index. The image is localized to the
+	// digest reference of the platform's manifest.
+	Platform *Platform `json:"platform,omitempty"`
+	// Select restricts the rule to the documents of the files matching the selector.
+	Select *DocumentSelector `json:"select,omitempty"`
+	// Strict fails the localization if the rule matches no file or document.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// Platform selects the manifest of a multi-arch image index by os, architecture and variant.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// SnapshotItem refers to a Resource object whose snapshot is stored in the in-cluster registry.
+// The Resource has to be in the namespace of the localized object, which the namespace defaults to.
+type SnapshotItem struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
 }
 
 type Mapping struct {
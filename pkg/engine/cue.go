@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/load"
+	securejoin "github.com/cyphar/filepath-securejoin"
+)
+
+// evaluateCUE evaluates the CUE package in the directory pkgPath within dir. The source directory
+// is the module root. The inputs are filled into the "values", "component" and "files" fields and
+// the "out" field must hold an object mapping file names to their content.
+func evaluateCUE(dir, pkgPath string, inputs Inputs) (map[string]any, error) {
+	pkgDir, err := securejoin.SecureJoin(dir, pkgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := load.Instances([]string{"."}, &load.Config{
+		ModuleRoot: dir,
+		Dir:        pkgDir,
+	})
+	if len(instances) != 1 {
+		return nil, fmt.Errorf("expected a single package, got %d", len(instances))
+	}
+
+	if err := instances[0].Err; err != nil {
+		return nil, err
+	}
+
+	cueCtx := cuecontext.New()
+	v := cueCtx.BuildInstance(instances[0])
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	v = v.FillPath(cue.ParsePath("values"), inputs.Values).
+		FillPath(cue.ParsePath("component"), inputs.Component).
+		FillPath(cue.ParsePath("files"), inputs.Files)
+
+	out := v.LookupPath(cue.ParsePath("out"))
+	if !out.Exists() {
+		return nil, errors.New("the package does not define out")
+	}
+
+	if err := out.Validate(cue.Concrete(true)); err != nil {
+		return nil, err
+	}
+
+	files := map[string]any{}
+	if err := out.Decode(&files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
@@ -0,0 +1,125 @@
+// Package engine evaluates the configuration programs declared by ConfigData.
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+)
+
+// Inputs are the data made available to a configuration program.
+type Inputs struct {
+	// Values are the configuration values merged with the defaults of the ConfigData.
+	Values map[string]any `json:"values"`
+	// Component is the spec of the component descriptor the ConfigData belongs to.
+	Component any `json:"component"`
+	// Files holds the content of the source files by their slash separated path.
+	Files map[string]string `json:"files"`
+}
+
+// Render evaluates the program declared by render within dir and writes the files it
+// produces into dir.
+func Render(render *configdata.Render, dir string, inputs Inputs) error {
+	var (
+		files map[string]any
+		err   error
+	)
+
+	switch render.Engine {
+	case configdata.JsonnetEngine:
+		files, err = evaluateJsonnet(dir, render.Path, inputs)
+	case configdata.CUEEngine:
+		files, err = evaluateCUE(dir, render.Path, inputs)
+	default:
+		return fmt.Errorf("unsupported render engine %q", render.Engine)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to evaluate %s program %s: %w", render.Engine, render.Path, err)
+	}
+
+	for name, value := range files {
+		if err := writeFile(dir, name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadFiles returns the content of all regular files in dir by their slash separated path.
+func ReadFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(rel)] = string(content)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source files: %w", err)
+	}
+
+	return files, nil
+}
+
+// writeFile writes a value produced by a program to name within dir. Strings are written
+// as they are, other values are encoded as YAML or JSON depending on the file extension.
+func writeFile(dir, name string, value any) error {
+	path, err := securejoin.SecureJoin(dir, name)
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	switch v := value.(type) {
+	case string:
+		content = []byte(v)
+	default:
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".yaml", ".yml":
+			content, err = yaml.Marshal(v)
+		default:
+			content, err = json.MarshalIndent(v, "", "  ")
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-component-model/ocm-controller/pkg/configdata"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name     string
+		render   *configdata.Render
+		files    map[string]string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name: "jsonnet program",
+			render: &configdata.Render{
+				Engine: configdata.JsonnetEngine,
+				Path:   "config/main.jsonnet",
+			},
+			files: map[string]string{
+				"config/main.jsonnet": `local lib = import 'lib.libsonnet';
+{
+  'generated/configmap.yaml': lib.configMap(std.extVar('component').name, std.extVar('values')),
+  'message.txt': std.extVar('files')['message.txt'] + '!',
+}
+`,
+				"config/lib.libsonnet": `{
+  configMap(name, values):: {
+    apiVersion: 'v1',
+    kind: 'ConfigMap',
+    metadata: { name: name },
+    data: { replicas: std.toString(values.replicas) },
+  },
+}
+`,
+				"message.txt": "hello",
+			},
+			expected: map[string]string{
+				"generated/configmap.yaml": "apiVersion: v1\ndata:\n  replicas: \"3\"\nkind: ConfigMap\nmetadata:\n  name: github.com/open-component-model/test\n",
+				"message.txt":              "hello!",
+			},
+		},
+		{
+			name: "jsonnet imports can't leave the source",
+			render: &configdata.Render{
+				Engine: configdata.JsonnetEngine,
+				Path:   "main.jsonnet",
+			},
+			files: map[string]string{
+				"main.jsonnet": `{ 'out.json': importstr '../../../../etc/hostname' }`,
+			},
+			err: "failed to import",
+		},
+		{
+			name: "cue package",
+			render: &configdata.Render{
+				Engine: configdata.CUEEngine,
+				Path:   "config",
+			},
+			files: map[string]string{
+				"config/main.cue": `package config
+
+values: replicas: int
+component: name: string
+files: [string]: string
+
+out: {
+	"generated/configmap.yaml": {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: name: component.name
+		data: replicas: "\(values.replicas)"
+	}
+	"message.txt": files["message.txt"] + "!"
+}
+`,
+				"message.txt": "hello",
+			},
+			expected: map[string]string{
+				"generated/configmap.yaml": "apiVersion: v1\ndata:\n  replicas: \"3\"\nkind: ConfigMap\nmetadata:\n  name: github.com/open-component-model/test\n",
+				"message.txt":              "hello!",
+			},
+		},
+		{
+			name: "cue package must define out",
+			render: &configdata.Render{
+				Engine: configdata.CUEEngine,
+				Path:   ".",
+			},
+			files: map[string]string{
+				"main.cue": "package config\n\nvalues: _\n",
+			},
+			err: "does not define out",
+		},
+		{
+			name: "unsupported engine",
+			render: &configdata.Render{
+				Engine: "starlark",
+				Path:   "main.star",
+			},
+			err: `unsupported render engine "starlark"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o750))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+			}
+
+			files, err := ReadFiles(dir)
+			require.NoError(t, err)
+
+			err = Render(tc.render, dir, Inputs{
+				Values: map[string]any{
+					"replicas": 3,
+				},
+				Component: map[string]any{
+					"name": "github.com/open-component-model/test",
+				},
+				Files: files,
+			})
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			for name, expected := range tc.expected {
+				content, err := os.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				assert.Equal(t, expected, string(content))
+			}
+		})
+	}
+}
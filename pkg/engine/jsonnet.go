@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/google/go-jsonnet"
+)
+
+// evaluateJsonnet evaluates the Jsonnet file at entrypoint within dir. The inputs are available
+// as the external variables "values", "component" and "files". The program must evaluate to an
+// object mapping file names to their content.
+func evaluateJsonnet(dir, entrypoint string, inputs Inputs) (map[string]any, error) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&sourceImporter{dir: dir, cache: map[string]jsonnet.Contents{}})
+
+	for name, input := range map[string]any{
+		"values":    inputs.Values,
+		"component": inputs.Component,
+		"files":     inputs.Files,
+	} {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+
+		vm.ExtCode(name, string(data))
+	}
+
+	output, err := vm.EvaluateFileMulti(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]any, len(output))
+	for name, data := range output {
+		var value any
+		if err := json.Unmarshal([]byte(data), &value); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal output %s: %w", name, err)
+		}
+
+		files[name] = value
+	}
+
+	return files, nil
+}
+
+// sourceImporter resolves Jsonnet imports relative to the importing file and
+// doesn't allow them to leave the source directory.
+type sourceImporter struct {
+	dir   string
+	cache map[string]jsonnet.Contents
+}
+
+func (i *sourceImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	foundAt := path.Join(path.Dir(importedFrom), importedPath)
+	if path.IsAbs(importedPath) {
+		foundAt = path.Clean(importedPath)
+	}
+
+	if contents, ok := i.cache[foundAt]; ok {
+		return contents, foundAt, nil
+	}
+
+	file, err := securejoin.SecureJoin(i.dir, foundAt)
+	if err != nil {
+		return jsonnet.Contents{}, "", err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return jsonnet.Contents{}, "", fmt.Errorf("failed to import %s: %w", importedPath, err)
+	}
+
+	contents := jsonnet.MakeContentsRaw(data)
+	i.cache[foundAt] = contents
+
+	return contents, foundAt, nil
+}
@@ -52,6 +52,9 @@ type Resource[M any] struct {
 	// AccessOptions to modify the access of the resource.
 	AccessOptions []AccessOptionFunc
 	ExtraIdentity ocmmetav1.Identity
+
+	// Digest recorded for the resource in the component descriptor. The content isn't verified if it's not set.
+	Digest *ocmmetav1.DigestSpec
 }
 
 // Sign defines the two needed values to perform a component signing.
@@ -428,6 +431,7 @@ func (r *Resource[M]) Meta() *ocm.ResourceMeta {
 		},
 		Type:     r.Type,
 		Relation: r.Relation,
+		Digest:   r.Digest,
 	}
 }
 
@@ -0,0 +1,45 @@
+package fakes
+
+import (
+	"context"
+
+	"github.com/open-component-model/ocm-controller/pkg/helm"
+)
+
+// RenderArguments defines the arguments Render has been called with.
+type RenderArguments struct {
+	ChartData []byte
+	Values    map[string]any
+	Options   helm.Options
+}
+
+type FakeRenderer struct {
+	renderManifests  []byte
+	renderErr        error
+	renderCalledWith []RenderArguments
+}
+
+var _ helm.Renderer = &FakeRenderer{}
+
+func (f *FakeRenderer) Render(_ context.Context, chartData []byte, values map[string]any, opts helm.Options) ([]byte, error) {
+	f.renderCalledWith = append(f.renderCalledWith, RenderArguments{
+		ChartData: chartData,
+		Values:    values,
+		Options:   opts,
+	})
+
+	return f.renderManifests, f.renderErr
+}
+
+func (f *FakeRenderer) RenderReturns(manifests []byte, err error) {
+	f.renderManifests = manifests
+	f.renderErr = err
+}
+
+func (f *FakeRenderer) RenderCallingArgumentsOnCall(i int) RenderArguments {
+	return f.renderCalledWith[i]
+}
+
+func (f *FakeRenderer) RenderWasNotCalled() bool {
+	return len(f.renderCalledWith) == 0
+}
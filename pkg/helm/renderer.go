@@ -0,0 +1,20 @@
+package helm
+
+import (
+	"context"
+)
+
+// Options configures how a chart is rendered.
+type Options struct {
+	ReleaseName string
+	Namespace   string
+	KubeVersion string
+	APIVersions []string
+	IncludeCRDs bool
+}
+
+// Renderer renders Helm charts into plain manifests. The implementation using the Helm SDK lives in
+// the sdk package, which keeps the SDK and its dependencies out of packages that only need the interface.
+type Renderer interface {
+	Render(ctx context.Context, chartData []byte, values map[string]any, opts Options) ([]byte, error)
+}
@@ -0,0 +1,80 @@
+package sdk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/open-component-model/ocm-controller/pkg/helm"
+)
+
+// TemplateRenderer renders charts the way `helm template` does, without contacting a cluster.
+// Chart hooks are not part of the rendered manifests.
+type TemplateRenderer struct{}
+
+// NewTemplateRenderer creates a new renderer.
+func NewTemplateRenderer() *TemplateRenderer {
+	return &TemplateRenderer{}
+}
+
+var _ helm.Renderer = &TemplateRenderer{}
+
+func (r *TemplateRenderer) Render(ctx context.Context, chartData []byte, values map[string]any, opts helm.Options) ([]byte, error) {
+	// the chart loader expects a gzipped archive, but snapshots hold the plain tar.
+	if _, err := gzip.NewReader(bytes.NewReader(chartData)); err != nil {
+		compressed := &bytes.Buffer{}
+		gz := gzip.NewWriter(compressed)
+		if _, err := gz.Write(chartData); err != nil {
+			gz.Close()
+
+			return nil, err
+		}
+
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+
+		chartData = compressed.Bytes()
+	}
+
+	chart, err := loader.LoadArchive(bytes.NewReader(chartData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load helm chart: %w", err)
+	}
+
+	logger := log.FromContext(ctx)
+	install := action.NewInstall(&action.Configuration{
+		Log: func(format string, v ...any) {
+			logger.V(1).Info(fmt.Sprintf(format, v...))
+		},
+	})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = opts.ReleaseName
+	install.Namespace = opts.Namespace
+	install.IncludeCRDs = opts.IncludeCRDs
+	install.APIVersions = opts.APIVersions
+
+	if opts.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version %s: %w", opts.KubeVersion, err)
+		}
+
+		install.KubeVersion = kubeVersion
+	}
+
+	release, err := install.RunWithContext(ctx, chart, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render helm chart: %w", err)
+	}
+
+	return []byte(release.Manifest), nil
+}
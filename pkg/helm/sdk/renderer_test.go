@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-component-model/ocm-controller/pkg/helm"
+)
+
+func TestTemplateRenderer_Render(t *testing.T) {
+	files := map[string]string{
+		"test/Chart.yaml": "apiVersion: v2\nname: test\nversion: 0.1.0\n",
+		"test/templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+data:
+  replicas: "{{ .Values.replicas }}"
+  kubeVersion: {{ .Capabilities.KubeVersion.Version }}
+  monitoring: "{{ .Capabilities.APIVersions.Has "monitoring.coreos.com/v1" }}"
+`,
+	}
+
+	// snapshots hold the chart as plain tar
+	chart := &bytes.Buffer{}
+	tw := tar.NewWriter(chart)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	manifests, err := NewTemplateRenderer().Render(context.Background(), chart.Bytes(), map[string]any{"replicas": 3}, helm.Options{
+		ReleaseName: "release",
+		Namespace:   "apps",
+		KubeVersion: "1.30.0",
+		APIVersions: []string{"monitoring.coreos.com/v1"},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, string(manifests), "name: release")
+	assert.Contains(t, string(manifests), "namespace: apps")
+	assert.Contains(t, string(manifests), `replicas: "3"`)
+	assert.Contains(t, string(manifests), "kubeVersion: v1.30.0")
+	assert.Contains(t, string(manifests), `monitoring: "true"`)
+}
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/cache"
 )
 
 // Option is a functional option for Repository.
@@ -214,23 +215,49 @@ func NewRepository(repositoryName string, opts ...Option) (*Repository, error) {
 
 // PushData takes a blob of data and caches it using OCI as a background.
 func (c *Client) PushData(ctx context.Context, data io.ReadCloser, mediaType, name, tag string) (string, int64, error) {
+	result, err := c.PushArtifact(ctx, data, name, tag, cache.PushOptions{MediaType: mediaType})
+	if err != nil {
+		return "", -1, err
+	}
+
+	return result.Digest, result.Size, nil
+}
+
+// PushArtifact takes a blob of data and caches it as an OCI artifact described by the given options.
+// The returned manifest digest is the digest of exactly the pushed manifest, even if the tag is moved
+// by a concurrent push.
+func (c *Client) PushArtifact(ctx context.Context, data io.ReadCloser, name, tag string, opts cache.PushOptions) (cache.PushResult, error) {
 	repositoryName := fmt.Sprintf("%s/%s", c.OCIRepositoryAddr, name)
 	repo, err := NewRepository(repositoryName, c.WithTransport(ctx))
 	if err != nil {
-		return "", -1, fmt.Errorf("failed create new repository: %w", err)
+		return cache.PushResult{Size: -1}, fmt.Errorf("failed create new repository: %w", err)
 	}
 
-	manifest, err := repo.PushStreamingImage(tag, data, mediaType, nil)
+	image, err := repo.pushStreamingArtifact(tag, data, opts.ArtifactType, opts.MediaType, opts.Annotations)
 	if err != nil {
-		return "", -1, fmt.Errorf("failed to push image: %w", err)
+		return cache.PushResult{Size: -1}, fmt.Errorf("failed to push image: %w", err)
+	}
+
+	manifest, err := image.Manifest()
+	if err != nil {
+		return cache.PushResult{Size: -1}, fmt.Errorf("failed to get manifest: %w", err)
 	}
 
 	layers := manifest.Layers
 	if len(layers) == 0 {
-		return "", -1, fmt.Errorf("no layers returned by manifest: %w", err)
+		return cache.PushResult{Size: -1}, errors.New("no layers returned by manifest")
+	}
+
+	manifestDigest, err := image.Digest()
+	if err != nil {
+		return cache.PushResult{Size: -1}, fmt.Errorf("failed to get manifest digest: %w", err)
 	}
 
-	return layers[0].Digest.String(), layers[0].Size, nil
+	return cache.PushResult{
+		Digest:         layers[0].Digest.String(),
+		Size:           layers[0].Size,
+		ManifestDigest: manifestDigest.String(),
+	}, nil
 }
 
 // FetchDataByIdentity fetches an existing resource. Errors if there is no resource available. It's advised to call IsCached
@@ -285,6 +312,30 @@ func (c *Client) FetchDataByDigest(ctx context.Context, name, digest string) (io
 	return reader, nil
 }
 
+// VerifyData returns whether the blob with the given digest exists in the cache and its content matches the digest.
+func (c *Client) VerifyData(ctx context.Context, name, digest string) (bool, error) {
+	repositoryName := fmt.Sprintf("%s/%s", c.OCIRepositoryAddr, name)
+
+	repo, err := NewRepository(repositoryName, c.WithTransport(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	layer, err := repo.fetchBlob(digest)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch layer: %w", err)
+	}
+
+	// the digest is calculated over the data as stored, so the compressed content is verified.
+	reader, err := layer.Compressed()
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch blob: %w", err)
+	}
+	defer reader.Close()
+
+	return NewVerifier(digest).Verify(reader)
+}
+
 // IsCached returns whether a certain tag with a given name exists in cache.
 func (c *Client) IsCached(ctx context.Context, name, tag string) (bool, error) {
 	repositoryName := fmt.Sprintf("%s/%s", c.OCIRepositoryAddr, name)
@@ -411,6 +462,34 @@ func (r *Repository) PushStreamingImage(
 	mediaType string,
 	annotations map[string]string,
 ) (*v1.Manifest, error) {
+	return r.PushStreamingArtifact(reference, reader, "", mediaType, annotations)
+}
+
+// PushStreamingArtifact pushes a reader to the repository as a streaming OCI artifact.
+// The artifact type is declared as the config media type of an OCI image manifest, so
+// registries which do not support the artifactType field can still report it.
+// Helm chart content always gets the Helm config media type.
+func (r *Repository) PushStreamingArtifact(
+	reference string,
+	reader io.ReadCloser,
+	artifactType, mediaType string,
+	annotations map[string]string,
+) (*v1.Manifest, error) {
+	image, err := r.pushStreamingArtifact(reference, reader, artifactType, mediaType, annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	return image.Manifest()
+}
+
+// pushStreamingArtifact pushes a reader to the repository as a streaming OCI artifact and returns the pushed image.
+func (r *Repository) pushStreamingArtifact(
+	reference string,
+	reader io.ReadCloser,
+	artifactType, mediaType string,
+	annotations map[string]string,
+) (v1.Image, error) {
 	ref, err := parseReference(reference, r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse reference: %w", err)
@@ -430,7 +509,11 @@ func (r *Repository) PushStreamingImage(
 
 	// These MediaTypes are required to create a Helm compliant OCI repository.
 	if mediaType == registry.ChartLayerMediaType {
-		image = mutate.ConfigMediaType(image, registry.ConfigMediaType)
+		artifactType = registry.ConfigMediaType
+	}
+
+	if artifactType != "" {
+		image = mutate.ConfigMediaType(image, types.MediaType(artifactType))
 		image = mutate.MediaType(image, ocispec.MediaTypeImageManifest)
 	}
 
@@ -438,7 +521,7 @@ func (r *Repository) PushStreamingImage(
 		return nil, fmt.Errorf("failed to push image: %w", err)
 	}
 
-	return image.Manifest()
+	return image, nil
 }
 
 // pushImage pushes an OCI image to the repository. It accepts a v1.RepositoryURL interface.
@@ -3,6 +3,7 @@ package oci
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"strings"
 	"testing"
@@ -11,7 +12,9 @@ import (
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	. "github.com/onsi/gomega"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/registry"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/cache"
 	"github.com/open-component-model/ocm-controller/pkg/ocm"
 	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
 )
@@ -113,6 +117,56 @@ func TestRepository_StreamImage(t *testing.T) {
 	}
 }
 
+func TestRepository_StreamArtifact(t *testing.T) {
+	addr := strings.TrimPrefix(testServer.URL, "http://")
+	testCases := []struct {
+		name                 string
+		artifactType         string
+		mediaType            string
+		expectedArtifactType string
+	}{
+		{
+			name:                 "snapshot",
+			artifactType:         cache.SnapshotArtifactType,
+			mediaType:            cache.TarContentMediaType,
+			expectedArtifactType: cache.SnapshotArtifactType,
+		},
+		{
+			name:                 "helm chart",
+			artifactType:         cache.ResourceArtifactType,
+			mediaType:            registry.ChartLayerMediaType,
+			expectedArtifactType: registry.ConfigMediaType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			repoName := generateRandomName("testartifact")
+			repo, err := NewRepository(addr + "/" + repoName)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			annotations := map[string]string{
+				cache.AnnotationComponentName:    "github.com/open-component-model/test-component",
+				cache.AnnotationComponentVersion: "v0.0.1",
+			}
+			reader := io.NopCloser(bytes.NewBufferString("artifact"))
+			_, err = repo.PushStreamingArtifact("latest", reader, tc.artifactType, tc.mediaType, annotations)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			_, raw, err := repo.FetchManifest("latest", nil)
+			g.Expect(err).NotTo(HaveOccurred())
+			manifest := &ocispec.Manifest{}
+			g.Expect(json.Unmarshal(raw, manifest)).To(Succeed())
+			g.Expect(manifest.MediaType).To(Equal(ocispec.MediaTypeImageManifest))
+			g.Expect(manifest.Config.MediaType).To(Equal(tc.expectedArtifactType))
+			g.Expect(manifest.Layers).To(HaveLen(1))
+			g.Expect(manifest.Layers[0].MediaType).To(Equal(tc.mediaType))
+			g.Expect(manifest.Annotations).To(Equal(annotations))
+		})
+	}
+}
+
 func TestClient_FetchPush(t *testing.T) {
 	scheme := runtime.NewScheme()
 	assert.NoError(t, v1alpha1.AddToScheme(scheme))
@@ -305,3 +359,36 @@ func TestClient_DeleteData(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_VerifyData(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1.AddToScheme(scheme))
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ocm-registry-tls-certs",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"ca.crt":  []byte("file"),
+			"tls.crt": []byte("file"),
+			"tls.key": []byte("file"),
+		},
+		Type: "Opaque",
+	}
+	fakeClient := fake.NewClientBuilder().WithObjects(secret).WithScheme(scheme).Build()
+	addr := strings.TrimPrefix(testServer.URL, "http://")
+	c := NewClient(addr, WithClient(fakeClient), WithCertificateSecret("ocm-registry-tls-certs"), WithNamespace("default"))
+
+	digest, _, err := c.PushData(context.Background(), io.NopCloser(bytes.NewBufferString("verify")), "", "verify-data", "v0.0.1")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	verified, err := c.VerifyData(context.Background(), "verify-data", digest)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(verified).To(BeTrue())
+
+	_, err = c.VerifyData(context.Background(), "verify-data", "sha256:"+strings.Repeat("0", 64))
+	g.Expect(err).To(HaveOccurred())
+}
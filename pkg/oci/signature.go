@@ -0,0 +1,72 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// ResolveDigest returns the digest of the manifest a tag in the named repository points to.
+func (c *Client) ResolveDigest(ctx context.Context, name, tag string) (string, error) {
+	repositoryName := fmt.Sprintf("%s/%s", c.OCIRepositoryAddr, name)
+	repo, err := NewRepository(repositoryName, c.WithTransport(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed create new repository: %w", err)
+	}
+
+	ref, err := parseReference(tag, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse reference: %w", err)
+	}
+
+	desc, err := remote.Head(ref, repo.remoteOpts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch manifest descriptor: %w", err)
+	}
+
+	return desc.Digest.String(), nil
+}
+
+// PushSignature pushes a signature payload as the single layer of a manifest tagged with tag.
+// The annotations are set on the layer descriptor, which is where cosign compatible verifiers
+// look for the signature.
+func (c *Client) PushSignature(
+	ctx context.Context,
+	name, tag string,
+	payload []byte,
+	mediaType string,
+	annotations map[string]string,
+) error {
+	repositoryName := fmt.Sprintf("%s/%s", c.OCIRepositoryAddr, name)
+	repo, err := NewRepository(repositoryName, c.WithTransport(ctx))
+	if err != nil {
+		return fmt.Errorf("failed create new repository: %w", err)
+	}
+
+	ref, err := parseReference(tag, repo)
+	if err != nil {
+		return fmt.Errorf("failed to parse reference: %w", err)
+	}
+
+	image, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, types.MediaType(mediaType)),
+		Annotations: annotations,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append signature layer: %w", err)
+	}
+
+	image = mutate.MediaType(image, types.OCIManifestSchema1)
+	image = mutate.ConfigMediaType(image, types.OCIConfigJSON)
+
+	if err := repo.pushImage(image, ref); err != nil {
+		return fmt.Errorf("failed to push signature: %w", err)
+	}
+
+	return nil
+}
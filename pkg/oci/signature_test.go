@@ -0,0 +1,72 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClient_PushSignature(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1.AddToScheme(scheme))
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ocm-registry-tls-certs",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"ca.crt":  []byte("file"),
+			"tls.crt": []byte("file"),
+			"tls.key": []byte("file"),
+		},
+		Type: "Opaque",
+	}
+	fakeClient := fake.NewClientBuilder().WithObjects(secret).WithScheme(scheme).Build()
+	addr := strings.TrimPrefix(testServer.URL, "http://")
+	c := NewClient(addr, WithClient(fakeClient), WithCertificateSecret("ocm-registry-tls-certs"), WithNamespace("default"))
+
+	name := "signature-test"
+	_, _, err := c.PushData(context.Background(), io.NopCloser(bytes.NewBufferString("snapshot")), "", name, "v0.0.1")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	resolved, err := c.ResolveDigest(context.Background(), name, "v0.0.1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resolved).To(HavePrefix("sha256:"))
+
+	sigTag := strings.Replace(resolved, ":", "-", 1) + ".sig"
+	payload := []byte(`{"critical":{}}`)
+	annotations := map[string]string{"dev.cosignproject.cosign/signature": "c2lnbmF0dXJl"}
+	g.Expect(c.PushSignature(
+		context.Background(),
+		name,
+		sigTag,
+		payload,
+		"application/vnd.dev.cosign.simplesigning.v1+json",
+		annotations,
+	)).To(Succeed())
+
+	repo, err := NewRepository(addr + "/" + name)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, raw, err := repo.FetchManifest(sigTag, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var manifest ocispec.Manifest
+	g.Expect(json.Unmarshal(raw, &manifest)).To(Succeed())
+	g.Expect(manifest.Layers).To(HaveLen(1))
+	g.Expect(string(manifest.Layers[0].MediaType)).To(Equal("application/vnd.dev.cosign.simplesigning.v1+json"))
+	g.Expect(manifest.Layers[0].Annotations).To(Equal(annotations))
+}
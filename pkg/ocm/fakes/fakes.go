@@ -6,6 +6,8 @@ import (
 	"io"
 
 	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"ocm.software/ocm/api/ocm"
 
 	"github.com/open-component-model/ocm-controller/api/v1alpha1"
@@ -42,6 +44,12 @@ type MockFetcher struct {
 	listComponentVersionsCalledWith     [][]any
 	transferComponentErr                error
 	transferComponentCalledWith         [][]any
+	resolveImageDigestDigest            string
+	resolveImageDigestErr               error
+	resolveImageDigestCalledWith        [][]any
+	resolvePlatformDigestDigest         string
+	resolvePlatformDigestErr            error
+	resolvePlatformDigestCalledWith     [][]any
 }
 
 var _ ocmctrl.Contract = &MockFetcher{}
@@ -176,3 +184,39 @@ func (m *MockFetcher) TransferComponentReturns(err error) {
 func (m *MockFetcher) TransferComponentCallingArgumentsOnCall(i int) []any {
 	return m.transferComponentCalledWith[i]
 }
+
+func (m *MockFetcher) ResolveImageDigest(ctx context.Context, octx ocm.Context, ref name.Reference) (string, error) {
+	m.resolveImageDigestCalledWith = append(m.resolveImageDigestCalledWith, []any{ref})
+	return m.resolveImageDigestDigest, m.resolveImageDigestErr
+}
+
+func (m *MockFetcher) ResolveImageDigestReturns(digest string, err error) {
+	m.resolveImageDigestDigest = digest
+	m.resolveImageDigestErr = err
+}
+
+func (m *MockFetcher) ResolveImageDigestCallingArgumentsOnCall(i int) []any {
+	return m.resolveImageDigestCalledWith[i]
+}
+
+func (m *MockFetcher) ResolveImageDigestWasNotCalled() bool {
+	return len(m.resolveImageDigestCalledWith) == 0
+}
+
+func (m *MockFetcher) ResolvePlatformDigest(ctx context.Context, octx ocm.Context, ref name.Reference, platform v1.Platform) (string, error) {
+	m.resolvePlatformDigestCalledWith = append(m.resolvePlatformDigestCalledWith, []any{ref, platform})
+	return m.resolvePlatformDigestDigest, m.resolvePlatformDigestErr
+}
+
+func (m *MockFetcher) ResolvePlatformDigestReturns(digest string, err error) {
+	m.resolvePlatformDigestDigest = digest
+	m.resolvePlatformDigestErr = err
+}
+
+func (m *MockFetcher) ResolvePlatformDigestCallingArgumentsOnCall(i int) []any {
+	return m.resolvePlatformDigestCalledWith[i]
+}
+
+func (m *MockFetcher) ResolvePlatformDigestWasNotCalled() bool {
+	return len(m.resolvePlatformDigestCalledWith) == 0
+}
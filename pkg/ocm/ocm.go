@@ -3,6 +3,7 @@ package ocm
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -13,13 +14,20 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/mandelsoft/vfs/pkg/memoryfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/mitchellh/hashstructure/v2"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"go.podman.io/image/v5/pkg/compression"
 	"helm.sh/helm/v3/pkg/registry"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"ocm.software/ocm/api/credentials"
 	"ocm.software/ocm/api/credentials/extensions/repositories/dockerconfig"
 	"ocm.software/ocm/api/ocm"
 	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
@@ -31,6 +39,7 @@ import (
 	"ocm.software/ocm/api/ocm/tools/signing"
 	"ocm.software/ocm/api/ocm/tools/transfer"
 	"ocm.software/ocm/api/ocm/tools/transfer/transferhandler/standard"
+	"ocm.software/ocm/api/tech/oci/identity"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -41,6 +50,10 @@ import (
 
 const dockerConfigKey = ".dockerconfigjson"
 
+// ErrResourceDigestMismatch is returned if the content of a resource doesn't match the digest
+// recorded for it in the component descriptor.
+var ErrResourceDigestMismatch = errors.New("resource digest mismatch")
+
 // Contract defines a subset of capabilities from the OCM library.
 type Contract interface {
 	CreateAuthenticatedOCMContext(ctx context.Context, obj *v1alpha1.ComponentVersion) (ocm.Context, error)
@@ -63,6 +76,8 @@ type Contract interface {
 		obj *v1alpha1.ComponentVersion,
 		sourceComponentVersion ocm.ComponentVersionAccess,
 	) error
+	ResolveImageDigest(ctx context.Context, octx ocm.Context, ref name.Reference) (string, error)
+	ResolvePlatformDigest(ctx context.Context, octx ocm.Context, ref name.Reference, platform v1.Platform) (string, error)
 }
 
 // Client implements the OCM fetcher interface.
@@ -241,7 +256,7 @@ func (c *Client) GetResource(
 	}
 
 	// NewIdentity creates name based identity, and extra identity is added as a key value pair.
-	res, _, err := resourcerefs.ResolveResourceReference(
+	res, rcv, err := resourcerefs.ResolveResourceReference(
 		cva,
 		ocmmetav1.NewNestedResourceRef(ocmmetav1.NewIdentity(resource.Name, extras...), identities),
 		cva.Repository(),
@@ -253,8 +268,15 @@ func (c *Client) GetResource(
 			err,
 		)
 	}
+	defer func() {
+		if cerr := rcv.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+	}()
 
-	reader, mediaType, err := c.fetchResourceReader(res, cva)
+	// the resource is verified against the component version that declares it, which
+	// differs from cva if the resource is referenced through a reference path.
+	reader, mediaType, err := c.fetchResourceReader(res, rcv)
 	if err != nil {
 		return nil, "", -1, fmt.Errorf("failed to fetch reader for resource: %w", err)
 	}
@@ -274,12 +296,26 @@ func (c *Client) GetResource(
 		}
 	}()
 
-	// We need to push the media type... And construct the right layers I guess.
-	digest, size, err := c.cache.PushData(ctx, decompressedReader, mediaType, name, version)
+	if mediaType == "" {
+		mediaType = cache.BlobContentMediaType
+	}
+
+	annotations, err := resourceAnnotations(cv, identity, version)
+	if err != nil {
+		return nil, "", -1, fmt.Errorf("failed to construct annotations: %w", err)
+	}
+
+	pushed, err := c.cache.PushArtifact(ctx, decompressedReader, name, version, cache.PushOptions{
+		ArtifactType: cache.ResourceArtifactType,
+		MediaType:    mediaType,
+		Annotations:  annotations,
+	})
 	if err != nil {
 		return nil, "", -1, fmt.Errorf("failed to cache blob: %w", err)
 	}
 
+	digest, size := pushed.Digest, pushed.Size
+
 	// re-fetch the resource to have a streamed reader available
 	dataReader, err := c.cache.FetchDataByDigest(ctx, name, digest)
 	if err != nil {
@@ -289,6 +325,24 @@ func (c *Client) GetResource(
 	return dataReader, digest, size, nil
 }
 
+// resourceAnnotations returns the annotations describing which component a cached resource belongs to.
+func resourceAnnotations(cv *v1alpha1.ComponentVersion, identity ocmmetav1.Identity, version string) (map[string]string, error) {
+	id, err := json.Marshal(identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	return map[string]string{
+		ocispec.AnnotationTitle:          identity[v1alpha1.ResourceNameKey],
+		ocispec.AnnotationVersion:        version,
+		ocispec.AnnotationSource:         cv.GetRepositoryURL(),
+		ocispec.AnnotationVendor:         "ocm.software",
+		cache.AnnotationComponentName:    cv.Spec.Component,
+		cache.AnnotationComponentVersion: cv.Status.ReconciledVersion,
+		cache.AnnotationResourceIdentity: string(id),
+	}, nil
+}
+
 // GetComponentVersion returns a component Version. It's the caller's responsibility to clean it up and close the component Version once done with it.
 func (c *Client) GetComponentVersion(
 	_ context.Context,
@@ -563,29 +617,229 @@ func (c *Client) TransferComponent(
 	return nil
 }
 
-// We add this decision because OCM is storing the Helm artifact as an ociArtifact at the
-// time of this writing. This means, when fetching the resource via the normal route
-// it will return an OCI blob instead of the actual helm chart content.
-// Because of that, we need to create our own downloader when we are dealing with
-// helm charts.
-func (c *Client) fetchResourceReader(res ocm.ResourceAccess, cva ocm.ComponentVersionAccess) (_ io.ReadCloser, _ string, err error) {
-	if res.Meta().Type == "helmChart" {
-		return c.fetchHelmChartResource(res, cva, err)
+// ResolveImageDigest returns the digest of the manifest the image reference points to. The registry
+// is accessed with the credentials configured for it in the OCM context.
+func (c *Client) ResolveImageDigest(ctx context.Context, octx ocm.Context, ref name.Reference) (string, error) {
+	auth, err := registryAuth(octx, ref)
+	if err != nil {
+		return "", err
 	}
 
-	// use the plain resource reader
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest of %s: %w", ref, err)
+	}
+
+	return desc.Digest.String(), nil
+}
+
+// ResolvePlatformDigest returns the digest of the manifest for the platform if the image reference
+// points to an image index. The digest of the manifest is returned for references pointing to a
+// single platform image.
+func (c *Client) ResolvePlatformDigest(ctx context.Context, octx ocm.Context, ref name.Reference, platform v1.Platform) (string, error) {
+	auth, err := registryAuth(octx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch manifest of %s: %w", ref, err)
+	}
+
+	if !desc.MediaType.IsIndex() {
+		return desc.Digest.String(), nil
+	}
+
+	index, err := desc.ImageIndex()
+	if err != nil {
+		return "", fmt.Errorf("failed to read image index of %s: %w", ref, err)
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return "", fmt.Errorf("failed to read image index of %s: %w", ref, err)
+	}
+
+	for _, m := range manifest.Manifests {
+		if m.Platform != nil && m.Platform.Satisfies(platform) {
+			return m.Digest.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("image index %s has no manifest for platform %s", ref, platform.String())
+}
+
+// registryAuth returns the authenticator for the registry of the reference using the credentials
+// configured in the OCM context.
+func registryAuth(octx ocm.Context, ref name.Reference) (authn.Authenticator, error) {
+	creds, err := identity.GetCredentials(octx, ref.Context().RegistryStr(), ref.Context().RepositoryStr())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials for %s: %w", ref.Context().Name(), err)
+	}
+
+	if creds == nil {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      creds.GetProperty(credentials.ATTR_USERNAME),
+		Password:      creds.GetProperty(credentials.ATTR_PASSWORD),
+		IdentityToken: creds.GetProperty(credentials.ATTR_IDENTITY_TOKEN),
+	}), nil
+}
+
+// fetchResourceReader returns a reader for the content of the resource. Helm charts are fetched with
+// their own downloader, because OCM stores them as OCI artifacts and the plain resource reader would
+// return an OCI blob instead of the chart content.
+// The content is verified against the digest recorded in the component descriptor. Content whose digest
+// is the plain hash of the blob is verified while it is read, the returned reader then fails at the end
+// of mismatching content. The access method is released once the returned reader is closed.
+func (c *Client) fetchResourceReader(res ocm.ResourceAccess, cva ocm.ComponentVersionAccess) (_ io.ReadCloser, _ string, err error) {
 	access, err := res.AccessMethod()
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to fetch access spec: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			if cerr := access.Close(); cerr != nil {
+				err = errors.Join(err, cerr)
+			}
+		}
+	}()
 
+	if res.Meta().Type == "helmChart" {
+		// the downloaded chart differs from the stored artifact, so verify the artifact itself.
+		if err := verifyResourceDigest(res, cva, access); err != nil {
+			return nil, "", err
+		}
+
+		reader, mediaType, err := c.fetchHelmChartResource(res, cva, err)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return &resourceReader{Reader: reader, closers: []io.Closer{reader, access}}, mediaType, nil
+	}
+
+	// use the plain resource reader
 	reader, err := access.Reader()
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to fetch reader: %w", err)
 	}
 
 	// Ignore the media type as we set it to a default in OCI package
-	return reader, "", nil
+	result := &resourceReader{Reader: reader, closers: []io.Closer{reader, access}}
+
+	if res.Meta().Digest == nil {
+		return result, "", nil
+	}
+
+	if verifying, ok := newVerifyingReader(reader, res.Meta().Name, res.Meta().Digest); ok {
+		result.Reader = verifying
+
+		return result, "", nil
+	}
+
+	// the digest is normalised, e.g. for OCI artifacts, so it is verified the way OCM signing does
+	// before the content is read.
+	if err := verifyResourceDigest(res, cva, access); err != nil {
+		if cerr := reader.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+
+		return nil, "", err
+	}
+
+	return result, "", nil
+}
+
+// resourceReader reads the content of a resource and closes the reader and the access method
+// of the resource once it is closed.
+type resourceReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *resourceReader) Close() error {
+	var err error
+	for _, c := range r.closers {
+		err = errors.Join(err, c.Close())
+	}
+
+	return err
+}
+
+// genericBlobDigestV1 is the OCM normalisation of resources whose digest is the plain hash of their content.
+const genericBlobDigestV1 = "genericBlobDigest/v1"
+
+// blobDigestAlgorithms maps the OCM hash algorithms to the digest algorithms verifying them.
+var blobDigestAlgorithms = map[string]digest.Algorithm{
+	"SHA-256": digest.SHA256,
+	"SHA-512": digest.SHA512,
+}
+
+// verifyingReader verifies the content against its digest while it is read. A mismatch is returned
+// instead of the end of the content, so mismatching content is never consumed completely.
+type verifyingReader struct {
+	reader   io.Reader
+	verifier digest.Verifier
+	name     string
+	expected digest.Digest
+}
+
+// newVerifyingReader returns a reader verifying the content of reader against the digest spec of the resource.
+// It returns false if the digest isn't the plain hash of the content and can't be verified while streaming.
+func newVerifyingReader(reader io.Reader, name string, spec *ocmmetav1.DigestSpec) (io.Reader, bool) {
+	if spec.NormalisationAlgorithm != genericBlobDigestV1 {
+		return nil, false
+	}
+
+	algorithm, ok := blobDigestAlgorithms[spec.HashAlgorithm]
+	if !ok || !algorithm.Available() {
+		return nil, false
+	}
+
+	expected := digest.NewDigestFromEncoded(algorithm, spec.Value)
+	verifier := expected.Verifier()
+
+	return &verifyingReader{
+		reader:   io.TeeReader(reader, verifier),
+		verifier: verifier,
+		name:     name,
+		expected: expected,
+	}, true
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if errors.Is(err, io.EOF) && !r.verifier.Verified() {
+		return n, fmt.Errorf("%w: resource %s does not match digest %s", ErrResourceDigestMismatch, r.name, r.expected.Encoded())
+	}
+
+	return n, err
+}
+
+// verifyResourceDigest verifies the resource content against the digest in the component descriptor
+// using the same normalisation as OCM signing. Resources without a recorded digest are not verified.
+func verifyResourceDigest(res ocm.ResourceAccess, cva ocm.ComponentVersionAccess, data ocm.DataAccess) error {
+	if res.Meta().Digest == nil {
+		return nil
+	}
+
+	id := res.Meta().GetIdentity(cva.GetDescriptor().Resources)
+
+	ok, err := signing.VerifyResourceDigestByIdentity(cva, id, data)
+	if err != nil {
+		return fmt.Errorf("failed to verify digest of resource %s: %w", res.Meta().Name, err)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: resource %s does not match digest %s",
+			ErrResourceDigestMismatch, res.Meta().Name, res.Meta().Digest.Value)
+	}
+
+	return nil
 }
 
 func (c *Client) fetchHelmChartResource(res ocm.ResourceAccess, cva ocm.ComponentVersionAccess, err error) (io.ReadCloser, string, error) {
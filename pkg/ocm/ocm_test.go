@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"io"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +14,13 @@ import (
 	"go.podman.io/image/v5/pkg/compression"
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
 	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
@@ -121,6 +129,116 @@ func TestClient_GetResource(t *testing.T) {
 	assert.Equal(t, resourceRef.Version, args.Version)
 }
 
+func TestClient_GetResourceVerifiesDigest(t *testing.T) {
+	data := "testdata"
+
+	testCases := []struct {
+		name   string
+		digest string
+		err    error
+	}{
+		{
+			name:   "caches content matching the digest",
+			digest: "810ff2fb242a5dee4220f2cb0e6a519891fb67f2f828a6cab4ef8894633b1f50",
+		},
+		{
+			name:   "refuses content not matching the digest",
+			digest: "8fa155245ea8d3f2ea3add7d090d42dfb0e22799018fded6aae24f0c1a1c3f38",
+			err:    ErrResourceDigestMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			component := "ocm.software/ocm-demo-index"
+
+			octx := fakeocm.NewFakeOCMContext()
+
+			comp := &fakeocm.Component{
+				Name:    component,
+				Version: "v0.0.1",
+			}
+			res := &fakeocm.Resource[*ocm.ResourceMeta]{
+				Name:      "remote-controller-demo",
+				Version:   "v0.0.1",
+				Data:      []byte(data),
+				Component: comp,
+				Kind:      "localBlob",
+				Type:      "ociBlob",
+				Digest: &ocmmetav1.DigestSpec{
+					HashAlgorithm:          "SHA-256",
+					NormalisationAlgorithm: "genericBlobDigest/v1",
+					Value:                  tc.digest,
+				},
+			}
+			comp.Resources = append(comp.Resources, res)
+
+			_ = octx.AddComponent(comp)
+
+			cd := &v1alpha1.ComponentDescriptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "github.com-open-component-model-ocm-demo-index-v0.0.1-12345",
+				},
+				Spec: v1alpha1.ComponentDescriptorSpec{
+					Version: "v0.0.1",
+				},
+			}
+
+			cache := &fakes.FakeCache{}
+			cache.IsCachedReturns(false, nil)
+			cache.FetchDataByDigestReturns(io.NopCloser(strings.NewReader(data)), nil)
+			cache.PushDataReturns("sha256:"+tc.digest, nil)
+
+			ocmClient := NewClient(env.FakeKubeClient(WithObjects(cd)), cache)
+
+			cv := &v1alpha1.ComponentVersion{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-name",
+					Namespace: "default",
+				},
+				Spec: v1alpha1.ComponentVersionSpec{
+					Component: component,
+					Version: v1alpha1.Version{
+						Semver: "v0.0.1",
+					},
+					Repository: v1alpha1.Repository{
+						URL: "localhost",
+					},
+				},
+				Status: v1alpha1.ComponentVersionStatus{
+					ReconciledVersion: "v0.0.1",
+					ComponentDescriptor: v1alpha1.Reference{
+						Name:    component,
+						Version: "v0.0.1",
+						ComponentDescriptorRef: meta.NamespacedObjectReference{
+							Name:      cd.Name,
+							Namespace: cd.Namespace,
+						},
+					},
+				},
+			}
+
+			resourceRef := &v1alpha1.ResourceReference{
+				ElementMeta: v1alpha1.ElementMeta{
+					Name:    "remote-controller-demo",
+					Version: "v0.0.1",
+				},
+			}
+
+			_, _, _, err := ocmClient.GetResource(context.Background(), octx, cv, resourceRef)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, data, cache.PushDataCallingArgumentsOnCall(0).Content)
+		})
+	}
+}
+
 func TestClient_GetResourceFromNestedComponent(t *testing.T) {
 	component := "ocm.software/ocm-demo-index"
 	component2 := "ocm.software/ocm-demo-index-2"
@@ -1240,3 +1358,117 @@ func TestResourceLookupWithVersionAndExtraIdentity(t *testing.T) {
 	require.Equal(t, "sha-17579917197306559277", args.Name, "pushed name did not match constructed name from identity of the resource")
 	require.Equal(t, resourceRef.Version, args.Version)
 }
+
+func TestClient_ResolvePlatformDigest(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	amd64, err := random.Image(64, 1)
+	require.NoError(t, err)
+	arm64, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{
+			Add: amd64,
+			Descriptor: v1.Descriptor{
+				Platform: &v1.Platform{OS: "linux", Architecture: "amd64"},
+			},
+		},
+		mutate.IndexAddendum{
+			Add: arm64,
+			Descriptor: v1.Descriptor{
+				Platform: &v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+			},
+		},
+	)
+
+	indexRef, err := name.ParseReference(host + "/podinfo:6.3.5")
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(indexRef, index))
+
+	imageRef, err := name.ParseReference(host + "/busybox:1.36")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(imageRef, amd64))
+
+	ocmClient := NewClient(env.FakeKubeClient(), &fakes.FakeCache{})
+	octx := ocm.New()
+
+	amd64Digest, err := amd64.Digest()
+	require.NoError(t, err)
+	arm64Digest, err := arm64.Digest()
+	require.NoError(t, err)
+
+	dgst, err := ocmClient.ResolvePlatformDigest(context.Background(), octx, indexRef, v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"})
+	require.NoError(t, err)
+	assert.Equal(t, arm64Digest.String(), dgst)
+
+	dgst, err = ocmClient.ResolvePlatformDigest(context.Background(), octx, imageRef, v1.Platform{OS: "linux", Architecture: "arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, amd64Digest.String(), dgst)
+
+	_, err = ocmClient.ResolvePlatformDigest(context.Background(), octx, indexRef, v1.Platform{OS: "windows", Architecture: "amd64"})
+	assert.ErrorContains(t, err, "has no manifest for platform windows/amd64")
+}
+
+func TestVerifyingReader(t *testing.T) {
+	content := "testdata"
+
+	testCases := []struct {
+		name      string
+		spec      ocmmetav1.DigestSpec
+		streaming bool
+		err       error
+	}{
+		{
+			name: "reads content matching a SHA-256 digest",
+			spec: ocmmetav1.DigestSpec{
+				HashAlgorithm:          "SHA-256",
+				NormalisationAlgorithm: "genericBlobDigest/v1",
+				Value:                  "810ff2fb242a5dee4220f2cb0e6a519891fb67f2f828a6cab4ef8894633b1f50",
+			},
+			streaming: true,
+		},
+		{
+			name: "fails at the end of content not matching the digest",
+			spec: ocmmetav1.DigestSpec{
+				HashAlgorithm:          "SHA-256",
+				NormalisationAlgorithm: "genericBlobDigest/v1",
+				Value:                  "8fa155245ea8d3f2ea3add7d090d42dfb0e22799018fded6aae24f0c1a1c3f38",
+			},
+			streaming: true,
+			err:       ErrResourceDigestMismatch,
+		},
+		{
+			name: "doesn't stream normalised digests",
+			spec: ocmmetav1.DigestSpec{
+				HashAlgorithm:          "SHA-256",
+				NormalisationAlgorithm: "ociArtifactDigest/v1",
+				Value:                  "810ff2fb242a5dee4220f2cb0e6a519891fb67f2f828a6cab4ef8894633b1f50",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, ok := newVerifyingReader(strings.NewReader(content), "resource", &tc.spec)
+			require.Equal(t, tc.streaming, ok)
+
+			if !ok {
+				return
+			}
+
+			data, err := io.ReadAll(reader)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		})
+	}
+}
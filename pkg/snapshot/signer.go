@@ -0,0 +1,219 @@
+package snapshot
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CosignPrivateKey is the key in the signing Secret holding the PEM encoded private key.
+	CosignPrivateKey = "cosign.key"
+	// CosignPublicKey is the key in the signing Secret holding the PEM encoded public key.
+	// Flux expects public keys in verification Secrets to use the .pub extension.
+	CosignPublicKey = "cosign.pub"
+	// CosignProvider is the Flux verification provider matching the signatures created by CosignSigner.
+	CosignProvider = "cosign"
+
+	cosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	cosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureType          = "cosign container image signature"
+)
+
+// Signer signs snapshots after they have been pushed into the cache.
+type Signer interface {
+	// Sign creates and stores a signature for the snapshot manifest with the given digest in the named
+	// repository. The digest is the one returned by the push, not resolved from a tag, which could have
+	// been moved in the meantime.
+	Sign(ctx context.Context, name, manifestDigest string) error
+	// PublicKey returns the PEM encoded public key that verifies the created signatures.
+	PublicKey(ctx context.Context) ([]byte, error)
+}
+
+// SignatureStore provides the registry operations needed to attach a signature to a snapshot.
+type SignatureStore interface {
+	PushSignature(
+		ctx context.Context,
+		name, tag string,
+		payload []byte,
+		mediaType string,
+		annotations map[string]string,
+	) error
+}
+
+// CosignSigner signs snapshots with a key pair stored in a Secret. Signatures are
+// stored next to the snapshot using the cosign tag and payload format, so they can be
+// verified by Flux using the cosign provider.
+type CosignSigner struct {
+	Client       client.Client
+	Store        SignatureStore
+	RegistryAddr string
+	SecretName   string
+	Namespace    string
+}
+
+// NewCosignSigner creates a signer using the key pair in the Secret secretName in namespace.
+func NewCosignSigner(
+	client client.Client,
+	store SignatureStore,
+	registryAddr, secretName, namespace string,
+) *CosignSigner {
+	return &CosignSigner{
+		Client:       client,
+		Store:        store,
+		RegistryAddr: registryAddr,
+		SecretName:   secretName,
+		Namespace:    namespace,
+	}
+}
+
+var _ Signer = &CosignSigner{}
+
+func (s *CosignSigner) Sign(ctx context.Context, name, manifestDigest string) error {
+	secret, err := s.getSecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := parsePrivateKey(secret.Data[CosignPrivateKey])
+	if err != nil {
+		return fmt.Errorf("failed to parse private key from secret %s: %w", s.SecretName, err)
+	}
+
+	hash, err := v1.NewHash(manifestDigest)
+	if err != nil {
+		return fmt.Errorf("failed to parse digest %s: %w", manifestDigest, err)
+	}
+
+	payload, err := simpleSigningPayload(fmt.Sprintf("%s/%s", s.RegistryAddr, name), manifestDigest)
+	if err != nil {
+		return fmt.Errorf("failed to construct signature payload: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	signature, err := key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign payload: %w", err)
+	}
+
+	annotations := map[string]string{
+		cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature),
+	}
+
+	signatureTag := fmt.Sprintf("%s-%s.sig", hash.Algorithm, hash.Hex)
+	if err := s.Store.PushSignature(ctx, name, signatureTag, payload, cosignSimpleSigningMediaType, annotations); err != nil {
+		return fmt.Errorf("failed to push signature: %w", err)
+	}
+
+	return nil
+}
+
+// PublicKey returns the public key stored in the signing Secret. If the Secret only
+// contains the private key, the public key is derived from it.
+func (s *CosignSigner) PublicKey(ctx context.Context) ([]byte, error) {
+	secret, err := s.getSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if pub, ok := secret.Data[CosignPublicKey]; ok && len(pub) > 0 {
+		return pub, nil
+	}
+
+	key, err := parsePrivateKey(secret.Data[CosignPrivateKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key from secret %s: %w", s.SecretName, err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func (s *CosignSigner) getSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Name: s.SecretName, Namespace: s.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get signing secret %s: %w", s.SecretName, err)
+	}
+
+	return secret, nil
+}
+
+// parsePrivateKey parses an unencrypted PEM encoded ECDSA or RSA private key.
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	var (
+		key any
+		err error
+	)
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q, encrypted keys are not supported", block.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// simpleSigningPayload returns the payload cosign signs for a manifest digest.
+func simpleSigningPayload(reference, manifestDigest string) ([]byte, error) {
+	type identity struct {
+		DockerReference string `json:"docker-reference"`
+	}
+	type image struct {
+		DockerManifestDigest string `json:"docker-manifest-digest"`
+	}
+	type critical struct {
+		Identity identity `json:"identity"`
+		Image    image    `json:"image"`
+		Type     string   `json:"type"`
+	}
+
+	return json.Marshal(struct {
+		Critical critical          `json:"critical"`
+		Optional map[string]string `json:"optional"`
+	}{
+		Critical: critical{
+			Identity: identity{DockerReference: reference},
+			Image:    image{DockerManifestDigest: manifestDigest},
+			Type:     cosignSignatureType,
+		},
+	})
+}
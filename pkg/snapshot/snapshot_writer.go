@@ -2,14 +2,18 @@ package snapshot
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -21,7 +25,26 @@ import (
 // Writer creates a snapshot using an artifact path as location for the snapshot
 // data.
 type Writer interface {
-	Write(ctx context.Context, owner v1alpha1.SnapshotWriter, sourceDir string, identity ocmmetav1.Identity) (string, int64, error)
+	Write(
+		ctx context.Context,
+		owner v1alpha1.SnapshotWriter,
+		sourceDir string,
+		identity ocmmetav1.Identity,
+		provenance Provenance,
+	) (string, int64, error)
+}
+
+// Provenance describes the inputs a snapshot has been created from. It is recorded
+// as annotations on the pushed artifact so a snapshot can be traced back from the registry.
+type Provenance struct {
+	// ComponentName is the name of the component the snapshot was produced from.
+	ComponentName string
+	// ComponentVersion is the version of the component the snapshot was produced from.
+	ComponentVersion string
+	// SourceDigest is the digest of the data that has been mutated.
+	SourceDigest string
+	// ConfigDigest is the digest of the configuration or patch applied to the source.
+	ConfigDigest string
 }
 
 // OCIWriter writes snapshot data into the cluster-local OCI cache.
@@ -29,6 +52,11 @@ type OCIWriter struct {
 	Client client.Client
 	Cache  cache.Cache
 	Scheme *runtime.Scheme
+	// Signer is optional. If set, every pushed snapshot is signed.
+	Signer Signer
+	// Retention is the number of snapshot revisions kept in the cache per owner.
+	// Revisions are not retained if set to zero.
+	Retention int
 }
 
 // NewOCIWriter creates a new OCI cache writer.
@@ -45,6 +73,7 @@ func (w *OCIWriter) Write(
 	owner v1alpha1.SnapshotWriter,
 	sourceDir string,
 	identity ocmmetav1.Identity,
+	provenance Provenance,
 ) (_ string, _ int64, err error) {
 	logger := log.FromContext(ctx).WithName("snapshot-writer")
 
@@ -91,13 +120,32 @@ func (w *OCIWriter) Write(
 		tag = v
 	}
 
-	snapshotDigest, size, err := w.Cache.PushData(ctx, file, "", name, tag)
+	annotations, err := w.annotations(owner, identity, tag, provenance)
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to construct annotations: %w", err)
+	}
+
+	pushed, err := w.Cache.PushArtifact(ctx, file, name, tag, cache.PushOptions{
+		ArtifactType: cache.SnapshotArtifactType,
+		MediaType:    cache.TarContentMediaType,
+		Annotations:  annotations,
+	})
 	if err != nil {
 		return "", -1, fmt.Errorf("failed to push blob to local registry: %w", err)
 	}
 
+	snapshotDigest, size := pushed.Digest, pushed.Size
+
 	logger.V(v1alpha1.LevelDebug).Info("pushed data to the cache with digest", "digest", snapshotDigest)
 
+	if w.Signer != nil {
+		if err := w.Signer.Sign(ctx, name, pushed.ManifestDigest); err != nil {
+			return "", -1, fmt.Errorf("failed to sign snapshot: %w", err)
+		}
+
+		logger.V(v1alpha1.LevelDebug).Info("signed snapshot", "name", name, "tag", tag, "manifest", pushed.ManifestDigest)
+	}
+
 	snapshotCR := &v1alpha1.Snapshot{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      owner.GetSnapshotName(),
@@ -123,7 +171,160 @@ func (w *OCIWriter) Write(
 		return "", -1, fmt.Errorf("failed to create or update component descriptor: %w", err)
 	}
 
+	if w.Retention > 0 {
+		revision := v1alpha1.SnapshotRevision{
+			Digest:           snapshotDigest,
+			Tag:              revisionTag(tag, snapshotDigest),
+			Repository:       name,
+			Created:          metav1.Now(),
+			ComponentVersion: annotations[cache.AnnotationComponentVersion],
+			ResourceVersion:  identity[v1alpha1.ResourceVersionKey],
+			SourceDigest:     provenance.SourceDigest,
+			ConfigDigest:     provenance.ConfigDigest,
+		}
+		if err := w.retainRevision(ctx, snapshotCR, artifactPath.Name(), name, revision, annotations); err != nil {
+			return "", -1, fmt.Errorf("failed to retain snapshot revision: %w", err)
+		}
+	}
+
 	logger.Info("snapshot successfully created/updated", "digest", snapshotDigest, "snapshot", snapshotCR)
 
 	return snapshotDigest, size, nil
 }
+
+// retainRevision pushes the snapshot data a second time under the revision tag, so it survives the
+// next rendering, records the revision in the snapshot status and removes revisions beyond the retention limit.
+// Revisions are removed from the repository they have been retained in, which differs from the current one
+// once the identity of the snapshot has changed.
+func (w *OCIWriter) retainRevision(
+	ctx context.Context,
+	snapshotCR *v1alpha1.Snapshot,
+	artifactPath, name string,
+	revision v1alpha1.SnapshotRevision,
+	annotations map[string]string,
+) error {
+	logger := log.FromContext(ctx).WithName("snapshot-writer")
+
+	revisions := []v1alpha1.SnapshotRevision{revision}
+	retained := false
+	for _, r := range snapshotCR.Status.Revisions {
+		if r.Tag == revision.Tag && r.GetRepository(name) == name {
+			retained = true
+
+			continue
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	if !retained {
+		file, err := os.Open(artifactPath)
+		if err != nil {
+			return fmt.Errorf("failed to open created archive: %w", err)
+		}
+
+		pushed, err := w.Cache.PushArtifact(ctx, file, name, revision.Tag, cache.PushOptions{
+			ArtifactType: cache.SnapshotArtifactType,
+			MediaType:    cache.TarContentMediaType,
+			Annotations:  annotations,
+		})
+		if closeErr := file.Close(); closeErr != nil && !errors.Is(closeErr, os.ErrClosed) {
+			err = errors.Join(err, closeErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to push revision %s: %w", revision.Tag, err)
+		}
+
+		if w.Signer != nil {
+			if err := w.Signer.Sign(ctx, name, pushed.ManifestDigest); err != nil {
+				return fmt.Errorf("failed to sign revision %s: %w", revision.Tag, err)
+			}
+		}
+	}
+
+	if len(revisions) > w.Retention {
+		for _, r := range revisions[w.Retention:] {
+			repository := r.GetRepository(name)
+			if err := w.Cache.DeleteData(ctx, repository, r.Tag); err != nil {
+				// The revision is dropped from the status regardless, the data might already be gone.
+				logger.Error(err, "failed to delete expired snapshot revision", "repository", repository, "tag", r.Tag)
+			}
+		}
+
+		revisions = revisions[:w.Retention]
+	}
+
+	patch := client.MergeFrom(snapshotCR.DeepCopy())
+	snapshotCR.Status.Revisions = revisions
+	if err := w.Client.Status().Patch(ctx, snapshotCR, patch); err != nil {
+		return fmt.Errorf("failed to update snapshot revisions: %w", err)
+	}
+
+	return nil
+}
+
+// revisionTag returns the tag under which a revision with the given digest is retained.
+func revisionTag(tag, digest string) string {
+	_, hex, found := strings.Cut(digest, ":")
+	if !found {
+		hex = digest
+	}
+
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+
+	return fmt.Sprintf("%s-%s", tag, hex)
+}
+
+// annotations returns the standard OCI and OCM specific annotations describing where a snapshot came from.
+// The annotations are derived from the inputs only, so rendering the same inputs again yields the same
+// manifest digest and doesn't trigger a new rollout.
+func (w *OCIWriter) annotations(
+	owner v1alpha1.SnapshotWriter,
+	identity ocmmetav1.Identity,
+	tag string,
+	provenance Provenance,
+) (map[string]string, error) {
+	gvk, err := apiutil.GVKForObject(owner, w.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kind of owner: %w", err)
+	}
+
+	id, err := json.Marshal(identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	componentName := provenance.ComponentName
+	if componentName == "" {
+		componentName = identity[v1alpha1.ComponentNameKey]
+	}
+
+	componentVersion := provenance.ComponentVersion
+	if componentVersion == "" {
+		componentVersion = identity[v1alpha1.ComponentVersionKey]
+	}
+
+	annotations := map[string]string{
+		ocispec.AnnotationTitle:          owner.GetSnapshotName(),
+		ocispec.AnnotationVersion:        tag,
+		ocispec.AnnotationVendor:         "ocm.software",
+		cache.AnnotationResourceIdentity: string(id),
+		cache.AnnotationOwner:            fmt.Sprintf("%s/%s/%s", gvk.Kind, owner.GetNamespace(), owner.GetName()),
+	}
+
+	for k, v := range map[string]string{
+		ocispec.AnnotationRevision:       provenance.SourceDigest,
+		cache.AnnotationComponentName:    componentName,
+		cache.AnnotationComponentVersion: componentVersion,
+		cache.AnnotationSourceDigest:     provenance.SourceDigest,
+		cache.AnnotationConfigDigest:     provenance.ConfigDigest,
+	} {
+		if v != "" {
+			annotations[k] = v
+		}
+	}
+
+	return annotations, nil
+}
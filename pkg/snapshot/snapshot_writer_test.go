@@ -0,0 +1,273 @@
+package snapshot
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ocmmetav1 "ocm.software/ocm/api/ocm/compdesc/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/open-component-model/ocm-controller/api/v1alpha1"
+	"github.com/open-component-model/ocm-controller/pkg/cache/fakes"
+	"github.com/open-component-model/ocm-controller/pkg/oci"
+	"github.com/open-component-model/ocm-controller/pkg/ocm"
+)
+
+func TestOCIWriter_Retention(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	owner := &v1alpha1.Localization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "localization",
+			Namespace: "default",
+		},
+		Status: v1alpha1.MutationStatus{
+			SnapshotName: "localization-snapshot",
+		},
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(owner).
+		WithStatusSubresource(&v1alpha1.Snapshot{}).
+		Build()
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "deploy.yaml"), []byte("kind: Deployment"), 0o600))
+
+	identity := ocmmetav1.Identity{
+		v1alpha1.ComponentNameKey:    "github.com/open-component-model/test",
+		v1alpha1.ComponentVersionKey: "v0.1.0",
+		v1alpha1.ResourceNameKey:     "manifests",
+		v1alpha1.ResourceVersionKey:  "v0.0.1",
+	}
+
+	fakeCache := &fakes.FakeCache{}
+	writer := NewOCIWriter(client, fakeCache, scheme)
+	writer.Retention = 2
+
+	digests := []string{
+		"sha256:" + strings.Repeat("a", 64),
+		"sha256:" + strings.Repeat("b", 64),
+		"sha256:" + strings.Repeat("c", 64),
+	}
+	for _, d := range digests {
+		fakeCache.PushDataReturns(d, nil)
+		_, _, err := writer.Write(context.Background(), owner, sourceDir, identity, Provenance{SourceDigest: d})
+		require.NoError(t, err)
+	}
+
+	snapshot := &v1alpha1.Snapshot{}
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{
+		Namespace: owner.Namespace,
+		Name:      owner.GetSnapshotName(),
+	}, snapshot))
+
+	assert.Equal(t, digests[2], snapshot.Spec.Digest)
+	require.Len(t, snapshot.Status.Revisions, 2)
+	assert.Equal(t, digests[2], snapshot.Status.Revisions[0].Digest)
+	assert.Equal(t, "v0.0.1-cccccccccccc", snapshot.Status.Revisions[0].Tag)
+	assert.Equal(t, "v0.1.0", snapshot.Status.Revisions[0].ComponentVersion)
+	assert.Equal(t, "v0.0.1", snapshot.Status.Revisions[0].ResourceVersion)
+	assert.Equal(t, digests[2], snapshot.Status.Revisions[0].SourceDigest)
+	assert.Equal(t, digests[1], snapshot.Status.Revisions[1].Digest)
+
+	// every write pushes the snapshot tag and the revision tag
+	assert.Equal(t, "v0.0.1", fakeCache.PushDataCallingArgumentsOnCall(4).Version)
+	assert.Equal(t, "v0.0.1-cccccccccccc", fakeCache.PushDataCallingArgumentsOnCall(5).Version)
+
+	// the oldest revision is removed from the cache
+	assert.Equal(t, "v0.0.1-aaaaaaaaaaaa", fakeCache.DeleteDataCallingArgumentsOnCall(0)[1])
+}
+
+func TestOCIWriter_RetentionAfterComponentVersionBump(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	owner := &v1alpha1.Localization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "localization",
+			Namespace: "default",
+		},
+		Status: v1alpha1.MutationStatus{
+			SnapshotName: "localization-snapshot",
+		},
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(owner).
+		WithStatusSubresource(&v1alpha1.Snapshot{}).
+		Build()
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "deploy.yaml"), []byte("kind: Deployment"), 0o600))
+
+	fakeCache := &fakes.FakeCache{}
+	writer := NewOCIWriter(client, fakeCache, scheme)
+	writer.Retention = 1
+
+	var repositories []string
+	for i, version := range []string{"v0.1.0", "v0.2.0"} {
+		identity := ocmmetav1.Identity{
+			v1alpha1.ComponentNameKey:    "github.com/open-component-model/test",
+			v1alpha1.ComponentVersionKey: version,
+			v1alpha1.ResourceNameKey:     "manifests",
+			v1alpha1.ResourceVersionKey:  "v0.0.1",
+		}
+		name, err := ocm.ConstructRepositoryName(identity)
+		require.NoError(t, err)
+		repositories = append(repositories, name)
+
+		fakeCache.PushDataReturns("sha256:"+strings.Repeat(string(rune('a'+i)), 64), nil)
+		_, _, err = writer.Write(context.Background(), owner, sourceDir, identity, Provenance{})
+		require.NoError(t, err)
+	}
+	require.NotEqual(t, repositories[0], repositories[1])
+
+	snapshot := &v1alpha1.Snapshot{}
+	require.NoError(t, client.Get(context.Background(), types.NamespacedName{
+		Namespace: owner.Namespace,
+		Name:      owner.GetSnapshotName(),
+	}, snapshot))
+
+	require.Len(t, snapshot.Status.Revisions, 1)
+	assert.Equal(t, repositories[1], snapshot.Status.Revisions[0].Repository)
+
+	// the expired revision is removed from the repository of the previous component version
+	assert.Equal(t, []any{repositories[0], "v0.0.1-aaaaaaaaaaaa"}, fakeCache.DeleteDataCallingArgumentsOnCall(0))
+}
+
+func TestOCIWriter_SameInputsSameManifest(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	owner := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "configuration",
+			Namespace: "default",
+		},
+		Status: v1alpha1.MutationStatus{
+			SnapshotName: "configuration-snapshot",
+		},
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(owner).
+		WithStatusSubresource(&v1alpha1.Snapshot{}).
+		Build()
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "deploy.yaml"), []byte("kind: Deployment"), 0o600))
+
+	identity := ocmmetav1.Identity{
+		v1alpha1.ComponentNameKey:    "github.com/open-component-model/test",
+		v1alpha1.ComponentVersionKey: "v0.1.0",
+		v1alpha1.ResourceNameKey:     "manifests",
+		v1alpha1.ResourceVersionKey:  "v0.0.1",
+	}
+	provenance := Provenance{SourceDigest: "sha256:" + strings.Repeat("a", 64)}
+
+	store := oci.NewClient(strings.TrimPrefix(server.URL, "http://"), oci.WithInsecureSkipVerify(true))
+	writer := NewOCIWriter(client, store, scheme)
+
+	name, err := ocm.ConstructRepositoryName(identity)
+	require.NoError(t, err)
+
+	var manifests []string
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			// the rendering is repeated at a later time, e.g. on the next interval
+			time.Sleep(time.Second)
+		}
+
+		_, _, err := writer.Write(context.Background(), owner, sourceDir, identity, provenance)
+		require.NoError(t, err)
+
+		manifest, err := store.ResolveDigest(context.Background(), name, "v0.0.1")
+		require.NoError(t, err)
+
+		manifests = append(manifests, manifest)
+	}
+
+	assert.Equal(t, manifests[0], manifests[1])
+}
+
+// recordingSigner records the manifests it has been asked to sign.
+type recordingSigner struct {
+	signed []string
+}
+
+func (s *recordingSigner) Sign(_ context.Context, name, manifestDigest string) error {
+	s.signed = append(s.signed, name+"@"+manifestDigest)
+
+	return nil
+}
+
+func (s *recordingSigner) PublicKey(context.Context) ([]byte, error) {
+	return nil, nil
+}
+
+func TestOCIWriter_SignsPushedManifest(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	owner := &v1alpha1.Configuration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "configuration",
+			Namespace: "default",
+		},
+		Status: v1alpha1.MutationStatus{
+			SnapshotName: "configuration-snapshot",
+		},
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(owner).
+		WithStatusSubresource(&v1alpha1.Snapshot{}).
+		Build()
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "deploy.yaml"), []byte("kind: Deployment"), 0o600))
+
+	identity := ocmmetav1.Identity{
+		v1alpha1.ComponentNameKey:    "github.com/open-component-model/test",
+		v1alpha1.ComponentVersionKey: "v0.1.0",
+		v1alpha1.ResourceNameKey:     "manifests",
+		v1alpha1.ResourceVersionKey:  "v0.0.1",
+	}
+
+	store := oci.NewClient(strings.TrimPrefix(server.URL, "http://"), oci.WithInsecureSkipVerify(true))
+	signer := &recordingSigner{}
+	writer := NewOCIWriter(client, store, scheme)
+	writer.Signer = signer
+	writer.Retention = 1
+
+	_, _, err := writer.Write(context.Background(), owner, sourceDir, identity, Provenance{})
+	require.NoError(t, err)
+
+	name, err := ocm.ConstructRepositoryName(identity)
+	require.NoError(t, err)
+
+	manifest, err := store.ResolveDigest(context.Background(), name, "v0.0.1")
+	require.NoError(t, err)
+
+	// the snapshot and its revision carry the same manifest, both signatures refer to the pushed digest
+	assert.Equal(t, []string{name + "@" + manifest, name + "@" + manifest}, signer.signed)
+}